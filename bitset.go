@@ -0,0 +1,97 @@
+package shikaku
+
+import "math/bits"
+
+// bitset is a fixed-size set of small non-negative integers, one bit each.
+type bitset []uint64
+
+// newBitset creates an empty bitset which can hold 0 <= i < n.
+func newBitset(n int) bitset {
+	return make(bitset, (n+63)/64)
+}
+
+// has returns true if i is in the set.
+func (b bitset) has(i int) bool {
+	return b[i/64]&(1<<uint(i%64)) != 0
+}
+
+// set adds i to the set.
+func (b bitset) set(i int) {
+	b[i/64] |= 1 << uint(i%64)
+}
+
+// unset removes i from the set.
+func (b bitset) unset(i int) {
+	b[i/64] &^= 1 << uint(i%64)
+}
+
+// reset removes everything from the set.
+func (b bitset) reset() {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// intersects returns true if anything is in both b and other, which must be
+// the same size.
+func (b bitset) intersects(other bitset) bool {
+	for i := range b {
+		if b[i]&other[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// rangeMask returns the bits of word w which are in the range [lo, hi).
+func rangeMask(w, lo, hi int) uint64 {
+	mask := ^uint64(0)
+	if start := lo - w*64; start > 0 {
+		mask &= ^uint64(0) << uint(start)
+	}
+	if end := hi - w*64; end < 64 {
+		mask &= ^uint64(0) >> uint(64-end)
+	}
+	return mask
+}
+
+// anyIn returns true if anything in the range [lo, hi) is in the set.
+func (b bitset) anyIn(lo, hi int) bool {
+	for w := lo / 64; w*64 < hi; w++ {
+		if b[w]&rangeMask(w, lo, hi) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// countIn returns how many of the range [lo, hi) are in the set.
+func (b bitset) countIn(lo, hi int) int {
+	count := 0
+	for w := lo / 64; w*64 < hi; w++ {
+		count += bits.OnesCount64(b[w] & rangeMask(w, lo, hi))
+	}
+	return count
+}
+
+// setIn adds the range [lo, hi) to the set, returning how many of them
+// weren't in it already.
+func (b bitset) setIn(lo, hi int) (added int) {
+	for w := lo / 64; w*64 < hi; w++ {
+		mask := rangeMask(w, lo, hi)
+		added += bits.OnesCount64(mask &^ b[w])
+		b[w] |= mask
+	}
+	return added
+}
+
+// nextIn returns the smallest i in the range [lo, hi) which is in the set, or
+// -1 if there isn't one.
+func (b bitset) nextIn(lo, hi int) int {
+	for w := lo / 64; w*64 < hi; w++ {
+		if word := b[w] & rangeMask(w, lo, hi); word != 0 {
+			return w*64 + bits.TrailingZeros64(word)
+		}
+	}
+	return -1
+}
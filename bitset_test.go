@@ -0,0 +1,40 @@
+package shikaku
+
+import "testing"
+
+func TestBitsetRanges(t *testing.T) {
+	b := newBitset(200)
+
+	if added := b.setIn(60, 130); added != 70 {
+		t.Errorf("setIn(60, 130) added %d, expected 70", added)
+	}
+	if added := b.setIn(50, 70); added != 10 {
+		t.Errorf("setIn(50, 70) added %d, expected 10", added)
+	}
+
+	for i := 0; i < 200; i++ {
+		if b.has(i) != (i >= 50 && i < 130) {
+			t.Errorf("has(%d) = %v", i, b.has(i))
+		}
+	}
+
+	if b.anyIn(0, 50) || b.anyIn(130, 200) || !b.anyIn(129, 200) {
+		t.Error("anyIn doesn't match the range set")
+	}
+	if n := b.countIn(40, 140); n != 80 {
+		t.Errorf("countIn(40, 140) = %d, expected 80", n)
+	}
+
+	b.unset(50)
+	if i := b.nextIn(0, 200); i != 51 {
+		t.Errorf("nextIn(0, 200) = %d, expected 51", i)
+	}
+	if i := b.nextIn(130, 200); i != -1 {
+		t.Errorf("nextIn(130, 200) = %d, expected -1", i)
+	}
+
+	b.reset()
+	if b.anyIn(0, 200) {
+		t.Error("reset didn't empty the set")
+	}
+}
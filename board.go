@@ -131,169 +131,105 @@ func NewBoardFromString(s string) (*Board, error) {
 	return b, nil
 }
 
-// Solve solves the Shikaku puzzle
-/*
-
-For each Given:
-	for each factor pair (each way around)
-		For each possible placement
-			add a potential answer to the blank
-
-For each Blank:
-	if it has 0 Possibles, abort with error.
-	Count those with len(possible) != 1
-
-If count(len(possible) != 1) is zero
-	Done
-else
-	Repeat everything
-
-*/
-func (bo *Board) Solve() error {
-	// Sanity check: all the squares, added together, actually cover the board
-	totalCovered := 0
-	bo.Iter(func(pos Vec2, sq *Square) bool {
-		totalCovered += sq.Area
-		return true
-	})
-	if totalCovered > bo.Height()*bo.Width() {
-		return errors.New("Covered area is greater than board area")
-	}
-	if totalCovered < bo.Height()*bo.Width() {
-		return errors.New("Covered area is less than board area")
+// Candidates returns every Rect which could enclose the given at pos: those
+// with the given's area, which fit on the board, and which don't collide with
+// anything final. If the given is already solved, only its final Rect is
+// returned. Blank squares have no candidates.
+func (bo *Board) Candidates(pos Vec2) []Rect {
+	giv := bo.Get(pos)
+	if !IsGiven(*giv) {
+		return nil
+	} else if !IsUnsolvedGiven(*giv) {
+		return []Rect{giv.Final}
 	}
 
-	// Finalize if only one solution for anything.
-	// So count the number of times something's finalized.
-	countFinalized := 0
+	candidates := []Rect{}
+	placements(pos, giv.Area, bo.Size(), func(r Rect) {
+		// ...That doesn't collide
+		if !bo.Collides(r) {
+			candidates = append(candidates, r)
+		}
+	})
+	return candidates
+}
 
-	// For each Given
-	bo.IterWhere(IsUnsolvedGiven, func(pos Vec2, giv *Square) bool {
-
-		// Count possible orientations. If there's only 1, finalize it.
-		countPossible := 0
-		var lastPossible Rect
-
-		// For each factor pair...
-		factors := Factor(giv.Area)
-		for _, area := range factors {
-
-			// ...each way around
-			for flip := 0; flip <= 1; flip++ {
-
-				// For each possible placement...
-				var ofs Vec2 // Offset of top left corner to Given loc
-				for ofs[0] = 0; ofs[0] < area[0]; ofs[0]++ {
-					for ofs[1] = 0; ofs[1] < area[1]; ofs[1]++ {
-						a := pos.Sub(ofs)
-						b := a.Add(area)
-						r := Rect{a, b, pos}
-
-						// ...That doesn't collide, and that fits
-						if bo.Contains(r) && !bo.Collides(r) {
-							// incr possible count, set lastPossible
-							countPossible++
-							lastPossible = r
-
-							// Add a Potential for each square in the area.
-							bo.IterIn(a, b, func(pos Vec2, potential *Square) bool {
-								if potential != giv {
-									potential.AddPossible(r)
-								}
-								return true
-							})
-						}
-					}
+// placements calls visitor with every Rect of the given area around the given
+// at pos, which fits on a board of the given size.
+func placements(pos Vec2, area int, size Vec2, visitor func(r Rect)) {
+	// For each factor pair...
+	for _, dims := range Factor(area) {
+
+		// ...each way around
+		for flip := 0; flip <= 1; flip++ {
+
+			// For each possible placement that fits, skipping offsets which
+			// would put a corner off the board...
+			var lo, hi Vec2
+			for i := range lo {
+				lo[i] = pos[i] + dims[i] - size[i]
+				if lo[i] < 0 {
+					lo[i] = 0
 				}
-
-				// Flip the factor pair, then try again.
-				// If it's a square, don't flip it.
-				if area[0] != area[1] {
-					area = area.Transpose()
-				} else {
-					break
+				hi[i] = dims[i]
+				if hi[i] > pos[i]+1 {
+					hi[i] = pos[i] + 1
 				}
 			}
 
-		}
-
-		// If there's only one solution...
-		if countPossible == 1 {
-			// Finalize that solution.
-			countFinalized += bo.Finalize(lastPossible)
-		}
-
-		return true // keep going
-	})
-
-	// For each Blank
-	remaining := 0
-	valid := bo.IterWhere(IsNotFinal, func(pos Vec2, blank *Square) bool {
-		if len(blank.Possible) == 0 {
-			return false // board is invalid, can't cover a square
-		} else if len(blank.Possible) != 0 {
-			remaining++ // one more remaining square to determine
-		}
-		return true
-	})
-
-	if !valid {
-		return errors.New("Invalid board, some squares weren't covered")
-	}
-
-	if remaining == 0 {
-		// Done. Everything's fine.
-		return nil
-	}
+			var ofs Vec2 // Offset of top left corner to Given loc
+			for ofs[0] = lo[0]; ofs[0] < hi[0]; ofs[0]++ {
+				for ofs[1] = lo[1]; ofs[1] < hi[1]; ofs[1]++ {
+					a := pos.Sub(ofs)
+					visitor(Rect{a, a.Add(dims), pos})
+				}
+			}
 
-	// Finalize squares with 1 suggestion, add to the count.
-	bo.Iter(func(pos Vec2, sq *Square) bool {
-		if IsNotFinal(*sq) && !IsGiven(*sq) {
-			if len(sq.Possible) == 1 {
-				sol := sq.Possible[0]
-				//Make final.
-				countFinalized += bo.Finalize(sol)
+			// Flip the factor pair, then try again.
+			// If it's a square, don't flip it.
+			if dims[0] != dims[1] {
+				dims = dims.Transpose()
+			} else {
+				break
 			}
 		}
-		return true
-	})
+	}
+}
 
-	if countFinalized == 0 {
-		// Can't deterministically solve.
-		// Iterate through the potential solutions, try all of them. bfs.
-		var sol *Board = nil
-		bo.IterWhere(IsNotFinal, func(pos Vec2, sq *Square) bool {
-			for _, poss := range sq.Possible {
-				newBoard := &Board{}
-				*newBoard = *bo // Copy the board
-				newBoard.Finalize(poss)
-				err := newBoard.Solve()
-				if err == nil {
-					// Copy the solution back to this board, return without error
-					sol = newBoard
-					return false
-				}
+// Clone returns a deep copy of the board, which can be modified without
+// affecting the original.
+func (bo *Board) Clone() *Board {
+	clone := &Board{Grid: make([][]Square, len(bo.Grid))}
+	for y, row := range bo.Grid {
+		clone.Grid[y] = make([]Square, len(row))
+		for x, sq := range row {
+			if sq.Possible != nil {
+				sq.Possible = append(make([]Rect, 0, cap(sq.Possible)), sq.Possible...)
 			}
-			return true
-		})
-
-		if sol != nil {
-			// If a solution was found, return without error
-			return nil
+			clone.Grid[y][x] = sq
 		}
+	}
+	return clone
+}
 
-		// Otherwise, throw error about possible solutions.
-		return errors.New("no possible solutions work")
+// copyFrom overwrites every square of bo with a deep copy of the matching
+// square in src. Both boards must be the same size.
+func (bo *Board) copyFrom(src *Board) {
+	for y, row := range src.Clone().Grid {
+		copy(bo.Grid[y], row)
 	}
+}
 
-	// Truncate lists of Possible
-	bo.IterWhere(IsNotFinal, func(pos Vec2, sq *Square) bool {
-		sq.Possible = sq.Possible[:0]
+// Rects returns the final Rect of each solved given, in row-major order of
+// their givens.
+func (bo *Board) Rects() []Rect {
+	rects := []Rect{}
+	bo.IterWhere(IsGiven, func(pos Vec2, sq *Square) bool {
+		if !IsUnsolvedGiven(*sq) {
+			rects = append(rects, sq.Final)
+		}
 		return true
 	})
-
-	// Try refining it again.
-	return bo.Solve()
+	return rects
 }
 
 // StringGiven returns a string representation of the board, in the same format as NewBoardFromString.
@@ -364,7 +300,7 @@ func (bo *Board) Contains(r Rect) bool {
 }
 
 // Collides determines if the Rect overlaps with any final squares not of its
-// own Given.
+// own Given, or any squares owned by another given.
 //
 // Preconditions:
 //   a[0] <= b[0]
@@ -372,7 +308,8 @@ func (bo *Board) Contains(r Rect) bool {
 // Panics otherwise.
 func (bo *Board) Collides(r Rect) bool {
 	return !bo.IterIn(r.A, r.B, func(pos Vec2, sq *Square) bool {
-		return (IsGiven(*sq) && pos == r.Given) || (IsFinal(*sq) && sq.Final == r) || IsNotFinal(*sq)
+		return (IsGiven(*sq) && pos == r.Given) || (IsFinal(*sq) && sq.Final == r) ||
+			(IsNotFinal(*sq) && (!IsOwned(*sq) || sq.Owner == r.Given))
 	})
 }
 
@@ -390,8 +327,27 @@ func (bo *Board) Finalize(r Rect) (count int) {
 
 		sq.Final = r
 		sq.Possible = sq.Possible[:0]
+		sq.Owned = false
 		return true
 	})
 
 	return countFinalized
 }
+
+// Own marks every square within r which isn't final as owned by r.Given, and
+// returns the count of squares changed. It panics if one is already owned by
+// another given.
+func (bo *Board) Own(r Rect) (count int) {
+	bo.IterIn(r.A, r.B, func(pos Vec2, sq *Square) bool {
+		if IsFinal(*sq) || (IsOwned(*sq) && sq.Owner == r.Given) {
+			return true
+		} else if IsOwned(*sq) {
+			panic("Can't Own() a square owned by another given")
+		}
+
+		sq.Owner, sq.Owned = r.Given, true
+		count++
+		return true
+	})
+	return count
+}
@@ -172,6 +172,38 @@ func TestCollides(t *testing.T) {
 	}
 }
 
+func TestOwn(t *testing.T) {
+	bo, _ := NewBoardFromString(`
+		-- -- 04
+		-- -- --
+		02 -- --
+	`)
+	top := Rect{Vec2{1, 0}, Vec2{3, 1}, Vec2{2, 0}}
+
+	if n := bo.Own(top); n != 1 {
+		t.Errorf("Owned %d squares, expected 1", n)
+	}
+	if sq := bo.Get(Vec2{1, 0}); !IsOwned(*sq) || sq.Owner != top.Given {
+		t.Errorf("Square isn't owned by %v: %v", top.Given, sq)
+	}
+	if n := bo.Own(top); n != 0 {
+		t.Errorf("Owned %d squares again, expected 0", n)
+	}
+
+	// Only the given owning a square can cover it.
+	if bo.Collides(Rect{Vec2{0, 0}, Vec2{3, 2}, Vec2{2, 0}}) {
+		t.Error("Collides with a square of its own given")
+	}
+	if !bo.Collides(Rect{Vec2{0, 0}, Vec2{2, 1}, Vec2{0, 2}}) {
+		t.Error("Doesn't collide with a square owned by another given")
+	}
+
+	bo.Finalize(Rect{Vec2{1, 0}, Vec2{3, 2}, Vec2{2, 0}})
+	if IsOwned(*bo.Get(Vec2{1, 0})) {
+		t.Error("Square is still owned once it's final")
+	}
+}
+
 func TestIter(t *testing.T) {
 	bo, _ := NewBoardFromString(`
 		-- -- 05 -- --
@@ -228,6 +260,23 @@ func TestIter(t *testing.T) {
 
 }
 
+func TestClone(t *testing.T) {
+	bo, _ := NewBoardFromString(testBoards[0])
+	bo.Get(Vec2{0, 0}).AddPossible(Rect{Vec2{0, 0}, Vec2{3, 1}, Vec2{2, 0}})
+	origStr := bo.DebugString()
+
+	clone := bo.Clone()
+	clone.Get(Vec2{0, 0}).Possible[0] = Rect{}
+	clone.Get(Vec2{0, 0}).AddPossible(Rect{Vec2{0, 0}, Vec2{1, 3}, Vec2{0, 2}})
+	clone.Finalize(Rect{Vec2{2, 0}, Vec2{5, 1}, Vec2{2, 0}})
+
+	if bo.DebugString() != origStr {
+		t.Error("Modifying a clone changed the original board")
+		t.Log("Original:\n" + origStr)
+		t.Log("Actual:\n" + bo.DebugString())
+	}
+}
+
 func TestSolve(t *testing.T) {
 	for _, boString := range testBoards {
 		t.Run("Board", func(t *testing.T) {
@@ -250,6 +299,28 @@ func TestSolve(t *testing.T) {
 	}
 }
 
+func TestSolveBranch(t *testing.T) {
+	// Branching on this board used to guess Possibles left over from the
+	// pass before, which collide with the Rect the branch was made for.
+	bo, _ := NewBoardFromString(`
+		-- -- -- -- -- --
+		06 -- -- -- 08 02
+		-- 02 02 -- -- 01
+		-- -- -- -- -- --
+		-- 06 -- -- -- 02
+		-- -- -- 06 -- 01`)
+	origStr := bo.String()
+
+	if err := bo.Solve(); err != nil {
+		t.Error("Couldn't find solution to solvable puzzle:", err)
+	}
+
+	if t.Failed() {
+		t.Log("Original:\n" + origStr)
+		t.Log("Actual:\n" + bo.String())
+	}
+}
+
 func TestBadSolve(t *testing.T) {
 	for _, boString := range testBadBoards {
 		t.Run("Board", func(t *testing.T) {
@@ -0,0 +1,149 @@
+package shikaku
+
+import (
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+// Branching is how the default solver chooses where to guess, when nothing
+// more can be deduced.
+type Branching int
+
+const (
+	// BranchFirst guesses at the first square which isn't final, in
+	// row-major order, trying its candidates in the order they were
+	// generated.
+	BranchFirst Branching = iota
+
+	// BranchMostConstrained guesses at the given or square with the fewest
+	// candidates left, trying the candidates which rule out the fewest
+	// others first.
+	BranchMostConstrained
+
+	// BranchRandom guesses where BranchMostConstrained would, but tries the
+	// candidates in a random order, chosen by SolveOptions.Seed.
+	BranchRandom
+)
+
+var branchingNames = []string{
+	BranchFirst:           "First",
+	BranchMostConstrained: "MostConstrained",
+	BranchRandom:          "Random",
+}
+
+// String returns the name of the Branching.
+func (b Branching) String() string {
+	if b < 0 || int(b) >= len(branchingNames) {
+		return fmt.Sprintf("Branching(%d)", int(b))
+	}
+	return branchingNames[b]
+}
+
+// branch chooses where to guess, returning the position guessed at and the
+// candidates to try there, in order. Only givens and squares in region are
+// guessed at, or any of them if region is nil.
+func (s *solver) branch(st *state, region bitset) (pos Vec2, possible []int) {
+	switch s.opts.Branching {
+	case BranchMostConstrained:
+		return st.mostConstrained(region)
+	case BranchRandom:
+		pos, possible = st.mostConstrained(region)
+		st.shuffle(possible, atomic.LoadInt64(&s.seed))
+		return pos, possible
+	default:
+		// The first unknown square has to be covered by one of its
+		// candidates.
+		c := 0
+		for st.isFinal(c) || (region != nil && !region.has(c)) {
+			c++
+		}
+		return st.pos(c), st.liveIn(c)
+	}
+}
+
+// mostConstrained finds the unsolved given, or the square which isn't final,
+// with the fewest live candidates, and orders them by how many other live
+// candidates placing them would rule out. Only givens and squares in region
+// are looked at, or every one if region is nil.
+func (st *state) mostConstrained(region bitset) (pos Vec2, possible []int) {
+	in := func(c int) bool {
+		return region == nil || region.has(c)
+	}
+
+	best := int32(-1)
+	for g, pos2 := range st.givens {
+		if st.placed[g] < 0 && in(st.cell(pos2)) && (best < 0 || st.givenLive[g] < best) {
+			best = st.givenLive[g]
+			pos = pos2
+			possible = possible[:0]
+			for id := st.first[g]; id < st.first[g+1]; id++ {
+				if st.live.has(id) {
+					possible = append(possible, id)
+				}
+			}
+		}
+	}
+	for c, count := range st.cellLive {
+		if !st.isFinal(c) && in(c) && (best < 0 || count < best) {
+			best = count
+			pos = st.pos(c)
+			possible = st.liveIn(c)
+		}
+	}
+
+	ruledOut := make([]int, len(possible))
+	for i, id := range possible {
+		ruledOut[i] = st.ruledOutBy(id)
+	}
+	sort.Stable(byKey{possible, ruledOut})
+	return pos, possible
+}
+
+// ruledOutBy counts the other live candidates which placing candidate id
+// would rule out.
+func (st *state) ruledOutBy(id int) int {
+	g := st.candGiven[id]
+	count := int(st.givenLive[g]) - 1
+
+	// Only count each candidate of another given once, however many of
+	// the cells they share.
+	seen := map[int32]bool{}
+	r := st.cands[id]
+	for y := r.A[1]; y < r.B[1]; y++ {
+		for x := r.A[0]; x < r.B[0]; x++ {
+			for _, other := range st.cellCands[st.cell(Vec2{x, y})] {
+				if st.candGiven[other] != g && st.live.has(int(other)) && !seen[other] {
+					seen[other] = true
+					count++
+				}
+			}
+		}
+	}
+	return count
+}
+
+// shuffle puts the candidate IDs in possible into a random order. The order
+// only depends on seed, and the candidates placed so far, so the same seed
+// always leads to the same guesses.
+func (st *state) shuffle(possible []int, seed int64) {
+	keys := make([]int, len(possible))
+	for i, id := range possible {
+		keys[i] = int(splitmix(uint64(seed)^st.hash^zobrist(id)) >> 1)
+	}
+	sort.Sort(byKey{possible, keys})
+}
+
+// byKey sorts candidate IDs by a key for each, like how many others each
+// rules out.
+type byKey struct {
+	ids  []int
+	keys []int
+}
+
+func (b byKey) Len() int           { return len(b.ids) }
+func (b byKey) Less(i, j int) bool { return b.keys[i] < b.keys[j] }
+func (b byKey) Swap(i, j int) {
+	b.ids[i], b.ids[j] = b.ids[j], b.ids[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
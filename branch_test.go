@@ -0,0 +1,110 @@
+package shikaku
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// This board has one solution, but without probing, needs several guesses to
+// find it.
+const testGuessBoard = `
+	-- -- -- -- 05 -- -- -- --
+	02 -- -- -- 04 -- -- -- --
+	-- -- -- -- -- -- -- -- --
+	02 -- 06 -- -- 05 10 -- 05
+	04 -- 03 -- -- -- -- -- --
+	-- -- -- -- -- -- -- -- 09
+	-- -- -- -- 08 -- -- -- --
+	-- -- 02 -- -- 03 -- -- --
+	06 -- -- 04 -- -- 02 -- 01`
+
+var testBranchings = []Branching{BranchFirst, BranchMostConstrained, BranchRandom}
+
+func TestBranching(t *testing.T) {
+	boards := append([]string{testBranchBoard, testGuessBoard}, testBoards...)
+	for _, branching := range testBranchings {
+		for _, boString := range boards {
+			t.Run(branching.String(), func(t *testing.T) {
+				bo, _ := NewBoardFromString(boString)
+				unique, _ := bo.IsUnique()
+				serial := bo.Clone()
+				serial.Solve()
+
+				_, err := bo.SolveContext(context.Background(), SolveOptions{Branching: branching, NoProbing: true})
+				if err != nil {
+					t.Fatal("Couldn't find solution to solvable puzzle:", err)
+				}
+
+				if unique && bo.String() != serial.String() {
+					t.Error("Solution differs from the one found branching on the first square")
+					t.Log("Expected:\n" + serial.String())
+					t.Log("Actual:\n" + bo.String())
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkBranching(b *testing.B) {
+	boards := append([]string{testBranchBoard, testGuessBoard}, testBoards...)
+	for _, branching := range testBranchings {
+		b.Run(branching.String(), func(b *testing.B) {
+			for _, boString := range boards {
+				bo, _ := NewBoardFromString(boString)
+				b.Run("Board", func(b *testing.B) {
+					nodes := 0
+					for i := 0; i < b.N; i++ {
+						_, stats, err := solveBacktrack(context.Background(), bo, SolveOptions{Branching: branching, NoProbing: true})
+						if err != nil {
+							b.Fatalf("Solve failed, see TestBranching for details")
+						}
+						nodes += stats.Nodes
+					}
+					b.ReportMetric(float64(nodes)/float64(b.N), "nodes/op")
+				})
+			}
+		})
+	}
+}
+
+func TestBranchRandom(t *testing.T) {
+	// solve returns the guesses made solving testGuessBoard with seed.
+	solve := func(seed int64) ([]Event, SolveStats) {
+		guesses := []Event{}
+		bo, _ := NewBoardFromString(testGuessBoard)
+		stats, err := bo.SolveContext(context.Background(), SolveOptions{
+			Branching: BranchRandom,
+			Seed:      seed,
+			NoProbing: true,
+			Observer: ObserverFunc(func(ev Event) {
+				if ev.Kind == EventBranch {
+					guesses = append(guesses, ev)
+				}
+			}),
+		})
+		if err != nil {
+			t.Fatal("Couldn't find solution to solvable puzzle:", err)
+		}
+		return guesses, stats
+	}
+
+	first, stats := solve(0)
+	if stats.Seed == 0 {
+		t.Fatal("No seed was chosen")
+	}
+	again, againStats := solve(stats.Seed)
+	if !reflect.DeepEqual(again, first) || againStats.Seed != stats.Seed {
+		t.Errorf("Solving with seed %d again guessed %v, expected %v", stats.Seed, again, first)
+	}
+
+	// Some seed tries the first guess in a different order.
+	different := false
+	for seed := int64(1); seed <= 20 && !different; seed++ {
+		guesses, _ := solve(seed)
+		different = guesses[0].Rect != first[0].Rect
+	}
+	if !different {
+		t.Error("Every seed made the same first guess")
+	}
+}
@@ -0,0 +1,166 @@
+package shikaku
+
+import "fmt"
+
+// ViolationKind is the kind of problem with a proposed solution.
+type ViolationKind int
+
+const (
+	// ViolationOutOfBounds is when a Rect doesn't fit on the board, or has
+	// no area. Pos is its top left corner. The rest of the checks only look
+	// at the part of it on the board.
+	ViolationOutOfBounds ViolationKind = iota
+
+	// ViolationNoGiven is when a Rect doesn't enclose any givens. Pos is its
+	// top left corner.
+	ViolationNoGiven
+
+	// ViolationManyGivens is when a Rect encloses more than one given. Pos is
+	// the second given it encloses, in row-major order.
+	ViolationManyGivens
+
+	// ViolationWrongGiven is when a Rect encloses one given, but its Given is
+	// somewhere else. Pos is the given it encloses.
+	ViolationWrongGiven
+
+	// ViolationWrongArea is when a Rect's area isn't its given's Area. Pos is
+	// the given it encloses.
+	ViolationWrongArea
+
+	// ViolationOverlap is when two Rects overlap. Pos is the top left corner
+	// of where they overlap, and Other is the earlier of the two.
+	ViolationOverlap
+
+	// ViolationUncovered is when a square isn't covered by any Rect. Pos is
+	// the square.
+	ViolationUncovered
+)
+
+var violationKindNames = []string{
+	ViolationOutOfBounds: "OutOfBounds",
+	ViolationNoGiven:     "NoGiven",
+	ViolationManyGivens:  "ManyGivens",
+	ViolationWrongGiven:  "WrongGiven",
+	ViolationWrongArea:   "WrongArea",
+	ViolationOverlap:     "Overlap",
+	ViolationUncovered:   "Uncovered",
+}
+
+// String returns the name of the ViolationKind.
+func (k ViolationKind) String() string {
+	if k < 0 || int(k) >= len(violationKindNames) {
+		return fmt.Sprintf("ViolationKind(%d)", int(k))
+	}
+	return violationKindNames[k]
+}
+
+// Violation is a single problem with a proposed solution.
+type Violation struct {
+	Kind ViolationKind
+	Pos  Vec2
+
+	// Rect is the Rect with the problem, unless Kind is ViolationUncovered.
+	Rect Rect
+
+	// Other is the Rect which Rect overlaps, for ViolationOverlap.
+	Other Rect
+}
+
+// String describes the Violation.
+func (v Violation) String() string {
+	switch v.Kind {
+	case ViolationOutOfBounds:
+		return fmt.Sprintf("%v doesn't fit on the board", v.Rect)
+	case ViolationNoGiven:
+		return fmt.Sprintf("%v doesn't enclose a given", v.Rect)
+	case ViolationManyGivens:
+		return fmt.Sprintf("%v also encloses the given at %v", v.Rect, v.Pos)
+	case ViolationWrongGiven:
+		return fmt.Sprintf("%v encloses the given at %v instead", v.Rect, v.Pos)
+	case ViolationWrongArea:
+		return fmt.Sprintf("%v has the wrong area for the given at %v", v.Rect, v.Pos)
+	case ViolationOverlap:
+		return fmt.Sprintf("%v overlaps %v at %v", v.Rect, v.Other, v.Pos)
+	case ViolationUncovered:
+		return fmt.Sprintf("square %v isn't covered", v.Pos)
+	default:
+		return fmt.Sprintf("%v at %v", v.Kind, v.Pos)
+	}
+}
+
+// CheckSolution checks a proposed solution to the board, and returns every
+// problem with it. A correct solution has no Violations. Neither the board
+// nor its final squares are taken into account, only its givens.
+func CheckSolution(board *Board, rects []Rect) []Violation {
+	violations := []Violation{}
+	clipped := make([]Rect, len(rects))
+
+	for i, r := range rects {
+		// Only look at the part of r on the board from here on.
+		clipped[i] = clip(r, board.Size())
+		if !board.Contains(r) || r.Width() <= 0 || r.Height() <= 0 {
+			violations = append(violations, Violation{Kind: ViolationOutOfBounds, Pos: r.A, Rect: r})
+		}
+
+		givens := []Vec2{}
+		board.IterIn(clipped[i].A, clipped[i].B, func(pos Vec2, sq *Square) bool {
+			if IsGiven(*sq) {
+				givens = append(givens, pos)
+			}
+			return true
+		})
+
+		switch {
+		case len(givens) == 0:
+			violations = append(violations, Violation{Kind: ViolationNoGiven, Pos: r.A, Rect: r})
+		case len(givens) > 1:
+			violations = append(violations, Violation{Kind: ViolationManyGivens, Pos: givens[1], Rect: r})
+		default:
+			if r.Given != givens[0] {
+				violations = append(violations, Violation{Kind: ViolationWrongGiven, Pos: givens[0], Rect: r})
+			}
+			if r.Width()*r.Height() != board.Get(givens[0]).Area {
+				violations = append(violations, Violation{Kind: ViolationWrongArea, Pos: givens[0], Rect: r})
+			}
+		}
+	}
+
+	for i, r := range clipped {
+		for j, other := range clipped[:i] {
+			a, b := max2(r.A, other.A), min2(r.B, other.B)
+			if a[0] < b[0] && a[1] < b[1] {
+				violations = append(violations, Violation{Kind: ViolationOverlap, Pos: a, Rect: rects[i], Other: rects[j]})
+			}
+		}
+	}
+
+	covered := make([][]bool, board.Height())
+	for y := range covered {
+		covered[y] = make([]bool, board.Width())
+	}
+	for _, r := range clipped {
+		for y := r.A[1]; y < r.B[1]; y++ {
+			for x := r.A[0]; x < r.B[0]; x++ {
+				covered[y][x] = true
+			}
+		}
+	}
+	board.Iter(func(pos Vec2, sq *Square) bool {
+		if !covered[pos[1]][pos[0]] {
+			violations = append(violations, Violation{Kind: ViolationUncovered, Pos: pos})
+		}
+		return true
+	})
+
+	return violations
+}
+
+// clip returns the part of r inside a board of the given size, which is empty
+// if there isn't any.
+func clip(r Rect, size Vec2) Rect {
+	r.A, r.B = max2(r.A, ORIGIN), min2(r.B, size)
+	if r.A[0] >= r.B[0] || r.A[1] >= r.B[1] {
+		return Rect{Given: r.Given}
+	}
+	return r
+}
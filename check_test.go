@@ -0,0 +1,78 @@
+package shikaku
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckSolution(t *testing.T) {
+	for _, boString := range testBoards {
+		bo, _ := NewBoardFromString(boString)
+		solved := bo.Clone()
+		solved.Solve()
+
+		if violations := CheckSolution(bo, solved.Rects()); len(violations) != 0 {
+			t.Errorf("Found violations in a correct solution: %v", violations)
+		}
+	}
+}
+
+func TestCheckSolutionViolations(t *testing.T) {
+	bo, _ := NewBoardFromString(`
+		03 -- 03
+		-- -- --
+		-- 03 --`)
+
+	left := Rect{Vec2{0, 0}, Vec2{1, 3}, Vec2{0, 0}}
+	middle := Rect{Vec2{1, 0}, Vec2{2, 3}, Vec2{1, 2}}
+	right := Rect{Vec2{2, 0}, Vec2{3, 3}, Vec2{2, 0}}
+	short := Rect{Vec2{1, 1}, Vec2{2, 3}, Vec2{1, 2}}
+	top := Rect{Vec2{0, 0}, Vec2{3, 1}, Vec2{0, 0}}
+	tall := Rect{Vec2{1, 1}, Vec2{2, 4}, Vec2{1, 2}}
+	empty := Rect{Vec2{0, 1}, Vec2{1, 2}, Vec2{0, 1}}
+	misnamed := Rect{Vec2{1, 0}, Vec2{2, 3}, Vec2{1, 1}}
+
+	type testCase struct {
+		Name       string
+		Rects      []Rect
+		Violations []Violation
+	}
+
+	tests := []testCase{
+		{"Correct", []Rect{left, middle, right}, []Violation{}},
+		{"Missing", []Rect{left, right}, []Violation{
+			{Kind: ViolationUncovered, Pos: Vec2{1, 0}},
+			{Kind: ViolationUncovered, Pos: Vec2{1, 1}},
+			{Kind: ViolationUncovered, Pos: Vec2{1, 2}},
+		}},
+		{"WrongArea", []Rect{left, short, right}, []Violation{
+			{Kind: ViolationWrongArea, Pos: Vec2{1, 2}, Rect: short},
+			{Kind: ViolationUncovered, Pos: Vec2{1, 0}},
+		}},
+		{"ManyGivens", []Rect{top, middle, right}, []Violation{
+			{Kind: ViolationManyGivens, Pos: Vec2{2, 0}, Rect: top},
+			{Kind: ViolationOverlap, Pos: Vec2{1, 0}, Rect: middle, Other: top},
+			{Kind: ViolationOverlap, Pos: Vec2{2, 0}, Rect: right, Other: top},
+			{Kind: ViolationUncovered, Pos: Vec2{0, 1}},
+			{Kind: ViolationUncovered, Pos: Vec2{0, 2}},
+		}},
+		{"OutOfBounds", []Rect{left, tall, right}, []Violation{
+			{Kind: ViolationOutOfBounds, Pos: Vec2{1, 1}, Rect: tall},
+			{Kind: ViolationUncovered, Pos: Vec2{1, 0}},
+		}},
+		{"NoGiven", []Rect{left, middle, right, empty}, []Violation{
+			{Kind: ViolationNoGiven, Pos: Vec2{0, 1}, Rect: empty},
+			{Kind: ViolationOverlap, Pos: Vec2{0, 1}, Rect: empty, Other: left},
+		}},
+		{"WrongGiven", []Rect{left, misnamed, right}, []Violation{
+			{Kind: ViolationWrongGiven, Pos: Vec2{1, 2}, Rect: misnamed},
+		}},
+	}
+
+	for _, test := range tests {
+		violations := CheckSolution(bo, test.Rects)
+		if !reflect.DeepEqual(violations, test.Violations) {
+			t.Errorf("%s: expected %v, got %v", test.Name, test.Violations, violations)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package shikaku
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CNF is a boolean formula in conjunctive normal form, which is satisfied by
+// exactly the solutions of a board.
+//
+// Variable v (counting from 1) is true when Vars[v-1] encloses its given.
+type CNF struct {
+	Vars []Rect
+
+	// Clauses are each a list of literals, at least one of which must be
+	// true: v for variable v, and -v for its negation.
+	Clauses [][]int
+}
+
+// EncodeCNF encodes the board as a CNF formula, with a variable for each of
+// the Candidates of each given. Each given must have exactly one of its
+// variables true, and each square must be enclosed by exactly one.
+func (bo *Board) EncodeCNF() *CNF {
+	cnf := &CNF{}
+
+	// Variables covering each square, by row then column.
+	covering := make([][][]int, bo.Height())
+	for y := range covering {
+		covering[y] = make([][]int, bo.Width())
+	}
+
+	bo.IterWhere(IsGiven, func(pos Vec2, sq *Square) bool {
+		vars := []int{}
+		for _, r := range bo.Candidates(pos) {
+			cnf.Vars = append(cnf.Vars, r)
+			v := len(cnf.Vars)
+			vars = append(vars, v)
+
+			bo.IterIn(r.A, r.B, func(pos Vec2, sq *Square) bool {
+				covering[pos[1]][pos[0]] = append(covering[pos[1]][pos[0]], v)
+				return true
+			})
+		}
+		cnf.exactlyOne(vars)
+		return true
+	})
+
+	bo.Iter(func(pos Vec2, sq *Square) bool {
+		cnf.exactlyOne(covering[pos[1]][pos[0]])
+		return true
+	})
+
+	return cnf
+}
+
+// exactlyOne adds clauses requiring exactly one of vars to be true: one
+// clause that any of them is, and one for each pair that they aren't both.
+func (cnf *CNF) exactlyOne(vars []int) {
+	cnf.Clauses = append(cnf.Clauses, append([]int{}, vars...))
+	for i := range vars {
+		for j := i + 1; j < len(vars); j++ {
+			cnf.Clauses = append(cnf.Clauses, []int{-vars[i], -vars[j]})
+		}
+	}
+}
+
+// WriteTo writes the formula in DIMACS format, with a comment line giving the
+// Rect of each variable, like:
+//
+//	c var 3 [0,1]-[2,3]@[1,1]
+func (cnf *CNF) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	write := func(format string, a ...interface{}) {
+		written, _ := fmt.Fprintf(bw, format, a...)
+		n += int64(written)
+	}
+
+	for i, r := range cnf.Vars {
+		write("c var %d %v\n", i+1, r)
+	}
+
+	write("p cnf %d %d\n", len(cnf.Vars), len(cnf.Clauses))
+	for _, clause := range cnf.Clauses {
+		for _, lit := range clause {
+			write("%d ", lit)
+		}
+		write("0\n")
+	}
+
+	return n, bw.Flush()
+}
+
+// ReadCNF reads the variables of a formula written by WriteTo back from its
+// comments. Its clauses aren't read.
+func ReadCNF(r io.Reader) (*CNF, error) {
+	cnf := &CNF{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "c var ") {
+			continue
+		}
+
+		var v int
+		var r Rect
+		_, err := fmt.Sscanf(line, "c var %d [%d,%d]-[%d,%d]@[%d,%d]",
+			&v, &r.A[0], &r.A[1], &r.B[0], &r.B[1], &r.Given[0], &r.Given[1])
+		if err != nil {
+			return nil, fmt.Errorf("Couldn't parse CNF variable '%s': %v", line, err)
+		}
+		if v != len(cnf.Vars)+1 {
+			return nil, fmt.Errorf("Couldn't parse CNF: variable %d out of order", v)
+		}
+		cnf.Vars = append(cnf.Vars, r)
+	}
+
+	return cnf, scanner.Err()
+}
+
+// DecodeModel reads a SAT solver's output for the formula, and returns the
+// Rects whose variables are true. The output should have the usual
+// "s SATISFIABLE" status line, followed by "v" lines listing the model, or be
+// a MiniSat result file, with a "SAT" line followed by the model's literals.
+// Lines with just the model's literals are accepted too.
+func (cnf *CNF) DecodeModel(r io.Reader) ([]Rect, error) {
+	rects := []Rect{}
+	satisfiable := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "c":
+			continue
+		case "s":
+			if strings.Join(fields[1:], " ") != "SATISFIABLE" {
+				return nil, fmt.Errorf("SAT solver didn't find a solution: %s", strings.Join(fields[1:], " "))
+			}
+			satisfiable = true
+			continue
+		case "SAT":
+			satisfiable = true
+			continue
+		case "UNSAT", "INDET":
+			return nil, fmt.Errorf("SAT solver didn't find a solution: %s", fields[0])
+		case "v":
+			fields = fields[1:]
+		}
+
+		for _, field := range fields {
+			lit, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("Couldn't parse model: '%s' isn't an int", field)
+			}
+			satisfiable = true
+
+			if lit > len(cnf.Vars) || -lit > len(cnf.Vars) {
+				return nil, fmt.Errorf("Couldn't parse model: no variable %d", lit)
+			} else if lit > 0 {
+				rects = append(rects, cnf.Vars[lit-1])
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !satisfiable {
+		return nil, errors.New("Couldn't parse model: no solution found")
+	}
+	return rects, nil
+}
+
+// ApplyModel reads a SAT solver's output for cnf, as in DecodeModel, and
+// finalizes each of the Rects it chose on the board. It returns an error
+// without changing the board if the Rects aren't a solution to it.
+func (bo *Board) ApplyModel(cnf *CNF, r io.Reader) error {
+	rects, err := cnf.DecodeModel(r)
+	if err != nil {
+		return err
+	}
+
+	// Each given needs exactly one Rect, and each square covering.
+	if violations := CheckSolution(bo, rects); len(violations) > 0 {
+		return fmt.Errorf("Model isn't a solution: %v", violations[0])
+	}
+
+	solved := bo.Clone()
+	for _, r := range rects {
+		if !solved.Contains(r) {
+			return fmt.Errorf("Model's Rect %v isn't on the board", r)
+		} else if solved.Collides(r) {
+			return fmt.Errorf("Model's Rect %v overlaps another", r)
+		}
+		solved.Finalize(r)
+	}
+
+	bo.copyFrom(solved)
+	return nil
+}
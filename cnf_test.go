@@ -0,0 +1,139 @@
+package shikaku
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCNFRoundTrip(t *testing.T) {
+	for _, boString := range testBoards {
+		t.Run("Board", func(t *testing.T) {
+			bo, _ := NewBoardFromString(boString)
+			cnf := bo.EncodeCNF()
+
+			var buf bytes.Buffer
+			if _, err := cnf.WriteTo(&buf); err != nil {
+				t.Fatal("Couldn't write CNF:", err)
+			}
+
+			read, err := ReadCNF(&buf)
+			if err != nil {
+				t.Fatal("Couldn't read CNF:", err)
+			}
+			if fmt.Sprint(read.Vars) != fmt.Sprint(cnf.Vars) {
+				t.Error("Variables changed reading back the CNF")
+			}
+		})
+	}
+}
+
+func TestCNFModel(t *testing.T) {
+	for _, boString := range testBoards {
+		t.Run("Board", func(t *testing.T) {
+			bo, _ := NewBoardFromString(boString)
+			cnf := bo.EncodeCNF()
+
+			solved := bo.Clone()
+			if err := solved.Solve(); err != nil {
+				t.Fatal("Couldn't find solution to solvable puzzle:", err)
+			}
+
+			// Write the model a SAT solver would give for the known solution.
+			model := map[int]bool{}
+			var out strings.Builder
+			out.WriteString("c from TestCNFModel\ns SATISFIABLE\nv")
+			for i, r := range cnf.Vars {
+				v := i + 1
+				model[v] = solved.Get(r.Given).Final == r
+				if model[v] {
+					fmt.Fprintf(&out, " %d", v)
+				} else {
+					fmt.Fprintf(&out, " %d", -v)
+				}
+			}
+			out.WriteString(" 0\n")
+
+			// That model should satisfy every clause.
+			for _, clause := range cnf.Clauses {
+				satisfied := false
+				for _, lit := range clause {
+					if (lit > 0 && model[lit]) || (lit < 0 && !model[-lit]) {
+						satisfied = true
+					}
+				}
+				if !satisfied {
+					t.Errorf("Solution doesn't satisfy clause %v", clause)
+				}
+			}
+
+			if err := bo.ApplyModel(cnf, strings.NewReader(out.String())); err != nil {
+				t.Fatal("Couldn't apply model:", err)
+			}
+			if bo.String() != solved.String() {
+				t.Error("Applying the model didn't solve the board")
+				t.Log("Expected:\n" + solved.String())
+				t.Log("Actual:\n" + bo.String())
+			}
+		})
+	}
+}
+
+func TestCNFUnsatisfiable(t *testing.T) {
+	bo, _ := NewBoardFromString(testBoards[0])
+	cnf := bo.EncodeCNF()
+
+	if err := bo.ApplyModel(cnf, strings.NewReader("s UNSATISFIABLE\n")); err == nil {
+		t.Error("Didn't blow up applying an unsatisfiable model")
+	}
+}
+
+func TestCNFMiniSat(t *testing.T) {
+	bo, _ := NewBoardFromString(testBoards[0])
+	cnf := bo.EncodeCNF()
+
+	solved := bo.Clone()
+	if err := solved.Solve(); err != nil {
+		t.Fatal("Couldn't find solution to solvable puzzle:", err)
+	}
+
+	// MiniSat's result file has a bare status line, then the literals.
+	var out strings.Builder
+	out.WriteString("SAT\n")
+	for i, r := range cnf.Vars {
+		if solved.Get(r.Given).Final == r {
+			fmt.Fprintf(&out, "%d ", i+1)
+		} else {
+			fmt.Fprintf(&out, "%d ", -(i + 1))
+		}
+	}
+	out.WriteString("0\n")
+
+	if err := bo.ApplyModel(cnf, strings.NewReader(out.String())); err != nil {
+		t.Fatal("Couldn't apply model:", err)
+	}
+	if bo.String() != solved.String() {
+		t.Error("Applying the model didn't solve the board")
+	}
+
+	if err := bo.ApplyModel(cnf, strings.NewReader("UNSAT\n")); err == nil {
+		t.Error("Didn't blow up applying an unsatisfiable model")
+	}
+}
+
+func TestCNFPartialModel(t *testing.T) {
+	bo, _ := NewBoardFromString(`
+		02 02
+		-- --`)
+	cnf := bo.EncodeCNF()
+	origStr := bo.DebugString()
+
+	// Only one of the givens gets a Rect.
+	if err := bo.ApplyModel(cnf, strings.NewReader("s SATISFIABLE\nv 1 0\n")); err == nil {
+		t.Error("Didn't blow up applying a partial model")
+	}
+	if bo.DebugString() != origStr {
+		t.Error("Applying a partial model modified the board")
+	}
+}
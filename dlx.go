@@ -0,0 +1,257 @@
+package shikaku
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync/atomic"
+)
+
+func init() {
+	RegisterSolver("dlx", SolverFunc(solveDLX))
+}
+
+// dlx is a sparse 0/1 matrix for Knuth's Algorithm X, stored as Dancing Links.
+//
+// Every 1 in the matrix is a node, linked in a circle to its neighbours in
+// the same row (left, right) and column (up, down). Nodes 1..cols are the
+// column headers, and node 0 is the root, linked to every uncovered header.
+type dlx struct {
+	left, right, up, down []int
+
+	// col is each node's column header, and row is the index in rows of the
+	// row each node belongs to (-1 for headers).
+	col, row []int
+
+	// size is the number of nodes in each column, indexed by header.
+	size []int
+
+	// rows holds the Rect each row of the matrix stands for.
+	rows []Rect
+
+	// pos holds the square each column stands for, indexed by header.
+	pos []Vec2
+
+	// chosen is the stack of rows in the current partial solution.
+	chosen []int
+}
+
+// newDLX creates an empty matrix with the given number of columns.
+func newDLX(cols int) *dlx {
+	d := &dlx{}
+	for i := 0; i <= cols; i++ {
+		d.left = append(d.left, i-1)
+		d.right = append(d.right, i+1)
+		d.up = append(d.up, i)
+		d.down = append(d.down, i)
+		d.col = append(d.col, i)
+		d.row = append(d.row, -1)
+		d.size = append(d.size, 0)
+		d.pos = append(d.pos, Vec2{})
+	}
+	d.left[0] = cols
+	d.right[cols] = 0
+	return d
+}
+
+// addRow adds a row standing for r, with 1s in each of cols.
+func (d *dlx) addRow(r Rect, cols []int) {
+	row := len(d.rows)
+	d.rows = append(d.rows, r)
+
+	first := len(d.col)
+	for i, c := range cols {
+		n := len(d.col)
+		d.col = append(d.col, c)
+		d.row = append(d.row, row)
+
+		// Link into the bottom of the column
+		d.up = append(d.up, d.up[c])
+		d.down = append(d.down, c)
+		d.down[d.up[c]] = n
+		d.up[c] = n
+		d.size[c]++
+
+		// Link into the end of the row
+		if i == 0 {
+			d.left = append(d.left, n)
+			d.right = append(d.right, n)
+		} else {
+			d.left = append(d.left, d.left[first])
+			d.right = append(d.right, first)
+			d.right[d.left[first]] = n
+			d.left[first] = n
+		}
+	}
+}
+
+// setPrimary links the columns in cols into the header list, in ascending
+// order, so they must be covered exactly once. Every other column is
+// unlinked, so it's covered at most once.
+func (d *dlx) setPrimary(cols []int) {
+	primary := make([]bool, len(d.size))
+	for _, c := range cols {
+		primary[c] = true
+	}
+
+	last := 0
+	for c := 1; c < len(d.size); c++ {
+		if primary[c] {
+			d.right[last] = c
+			d.left[c] = last
+			last = c
+		} else {
+			d.left[c], d.right[c] = c, c
+		}
+	}
+	d.right[last] = 0
+	d.left[0] = last
+}
+
+// cover removes column c from the header list, and every row with a 1 in c
+// from the other columns.
+func (d *dlx) cover(c int) {
+	d.right[d.left[c]] = d.right[c]
+	d.left[d.right[c]] = d.left[c]
+	for i := d.down[c]; i != c; i = d.down[i] {
+		for j := d.right[i]; j != i; j = d.right[j] {
+			d.down[d.up[j]] = d.down[j]
+			d.up[d.down[j]] = d.up[j]
+			d.size[d.col[j]]--
+		}
+	}
+}
+
+// uncover undoes cover(c).
+func (d *dlx) uncover(c int) {
+	for i := d.up[c]; i != c; i = d.up[i] {
+		for j := d.left[i]; j != i; j = d.left[j] {
+			d.size[d.col[j]]++
+			d.down[d.up[j]] = j
+			d.up[d.down[j]] = j
+		}
+	}
+	d.right[d.left[c]] = c
+	d.left[d.right[c]] = c
+}
+
+// search runs Algorithm X, leaving the first exact cover it finds in chosen.
+// Each row it tries counts as a search node of s.
+func (d *dlx) search(s *solver) error {
+	s.reached(len(d.chosen))
+	if d.right[0] == 0 {
+		return nil // Every column is covered.
+	}
+
+	// Branch on the column with the fewest rows left.
+	c := d.right[0]
+	for j := d.right[c]; j != 0; j = d.right[j] {
+		if d.size[j] < d.size[c] {
+			c = j
+		}
+	}
+	if d.size[c] == 0 {
+		return errors.New("no possible solutions work")
+	}
+
+	d.cover(c)
+	defer d.uncover(c)
+
+	for i := d.down[c]; i != c; i = d.down[i] {
+		if err := s.node(); err != nil {
+			return err
+		}
+
+		r := d.rows[d.row[i]]
+		s.emit(Event{Kind: EventBranch, Pos: d.pos[c], Rect: r, Depth: len(d.chosen)})
+		if d.size[c] == 1 {
+			atomic.AddInt64(&s.forced, 1) // It's the only row left to cover c.
+		} else {
+			atomic.AddInt64(&s.guessed, 1)
+		}
+		d.chosen = append(d.chosen, d.row[i])
+		for j := d.right[i]; j != i; j = d.right[j] {
+			d.cover(d.col[j])
+		}
+
+		err := d.search(s)
+
+		for j := d.left[i]; j != i; j = d.left[j] {
+			d.uncover(d.col[j])
+		}
+		if err == nil || isAbort(err) {
+			return err
+		}
+		d.chosen = d.chosen[:len(d.chosen)-1]
+		s.emit(Event{Kind: EventBranchFailed, Pos: d.pos[c], Rect: r, Depth: len(d.chosen)})
+	}
+
+	s.emit(Event{Kind: EventBacktrack, Pos: d.pos[c], Depth: len(d.chosen)})
+	atomic.AddInt64(&s.backtracks, 1)
+	return errors.New("no possible solutions work")
+}
+
+// newBoardDLX builds the exact cover matrix for a board. It has a column for
+// each square, which must be enclosed by exactly one Rect, and a column for
+// each given, which must have exactly one Rect. Each of the given's
+// Candidates is a row.
+func newBoardDLX(bo *Board) *dlx {
+	cells := bo.Width() * bo.Height()
+	givens := []Vec2{}
+	bo.IterWhere(IsGiven, func(pos Vec2, sq *Square) bool {
+		givens = append(givens, pos)
+		return true
+	})
+
+	d := newDLX(cells + len(givens))
+	bo.Iter(func(pos Vec2, sq *Square) bool {
+		d.pos[1+pos[1]*bo.Width()+pos[0]] = pos
+		return true
+	})
+	for g, pos := range givens {
+		d.pos[1+cells+g] = pos
+	}
+
+	for g, pos := range givens {
+		for _, r := range bo.Candidates(pos) {
+			cols := []int{1 + cells + g}
+			bo.IterIn(r.A, r.B, func(pos Vec2, sq *Square) bool {
+				cols = append(cols, 1+pos[1]*bo.Width()+pos[0])
+				return true
+			})
+			d.addRow(r, cols)
+		}
+	}
+
+	return d
+}
+
+// solveDLX solves the board as an exact cover problem, with Dancing Links.
+func solveDLX(ctx context.Context, bo *Board, opts SolveOptions) ([]Rect, SolveStats, error) {
+	if err := bo.checkArea(); err != nil {
+		return nil, SolveStats{}, err
+	}
+
+	s := newSolver(ctx, opts)
+	err := s.node()
+	d := newBoardDLX(bo)
+	atomic.AddInt64(&s.candidates, int64(len(d.rows)))
+	if err == nil {
+		err = d.search(s)
+	}
+
+	stats := s.stats()
+	if err != nil {
+		return nil, stats, explain(ctx, bo, err)
+	}
+
+	rects := []Rect{}
+	for _, row := range d.chosen {
+		rects = append(rects, d.rows[row])
+	}
+	sort.Slice(rects, func(i, j int) bool {
+		a, b := rects[i].Given, rects[j].Given
+		return a[1] < b[1] || (a[1] == b[1] && a[0] < b[0])
+	})
+	return rects, stats, nil
+}
@@ -1,16 +1,21 @@
 module github.com/wgoodall01/shikaku
 
+go 1.27.1
+
 // +heroku install ./www
 
 require (
 	github.com/NYTimes/gziphandler v1.0.1
 	github.com/bradleyjkemp/cupaloy v2.3.0+incompatible
-	github.com/certifi/gocertifi v0.0.0-20180905225744-ee1a9a0726d2 // indirect
-	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/fatih/color v1.7.0 // indirect
 	github.com/getsentry/raven-go v0.2.0
 	github.com/gorilla/handlers v1.4.0
 	github.com/gorilla/mux v1.6.2
+)
+
+require (
+	github.com/certifi/gocertifi v0.0.0-20180905225744-ee1a9a0726d2 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/fatih/color v1.7.0 // indirect
 	github.com/mattn/go-colorable v0.0.9 // indirect
 	github.com/mattn/go-isatty v0.0.4 // indirect
 	github.com/pkg/errors v0.8.0 // indirect
@@ -0,0 +1,135 @@
+package shikaku
+
+import (
+	"context"
+	"fmt"
+)
+
+// Difficulty is how hard a board is for a person to solve.
+type Difficulty int
+
+const (
+	// DifficultyEasy boards only need givens with a single possible Rect.
+	DifficultyEasy Difficulty = iota
+
+	// DifficultyMedium boards also need blanks which only one Rect can cover.
+	DifficultyMedium
+
+	// DifficultyHard boards need more advanced deductions, like squares
+	// which every possible Rect for a given covers, or Rects which lead
+	// straight to a contradiction.
+	DifficultyHard
+
+	// DifficultyExpert boards can't be solved by deduction alone, and need
+	// guessing and backtracking.
+	DifficultyExpert
+
+	// DifficultyInvalid boards can't be solved at all.
+	DifficultyInvalid
+)
+
+var difficultyNames = []string{
+	DifficultyEasy:    "Easy",
+	DifficultyMedium:  "Medium",
+	DifficultyHard:    "Hard",
+	DifficultyExpert:  "Expert",
+	DifficultyInvalid: "Invalid",
+}
+
+// String returns the name of the Difficulty.
+func (d Difficulty) String() string {
+	if d < 0 || int(d) >= len(difficultyNames) {
+		return fmt.Sprintf("Difficulty(%d)", int(d))
+	}
+	return difficultyNames[d]
+}
+
+// techniqueDifficulty is the Difficulty of a board needing each kind of step.
+var techniqueDifficulty = map[EventKind]Difficulty{
+	EventGivenForced:  DifficultyEasy,
+	EventBlankForced:  DifficultyMedium,
+	EventOverlap:      DifficultyHard,
+	EventProbe:        DifficultyHard,
+	EventBranch:       DifficultyExpert,
+	EventBranchFailed: DifficultyExpert,
+	EventBacktrack:    DifficultyExpert,
+}
+
+// Report describes how a board was graded.
+type Report struct {
+	// Steps are the deductions made to solve the board, in order, followed
+	// by every step of the search if guessing was needed.
+	Steps []Event
+
+	// Techniques counts the Steps of each kind.
+	Techniques map[EventKind]int
+
+	// Backtracking is true if the board needed guessing to solve.
+	Backtracking bool
+
+	// Search is the work the solver did guessing, if Backtracking.
+	Search SolveStats
+
+	// Err is why the board couldn't be solved, for DifficultyInvalid boards.
+	Err error
+}
+
+// Grade rates how hard a board is to solve, by solving it the way a person
+// would. It always makes the simplest deduction it can next, as NextHint does,
+// and only guesses once nothing more can be deduced. The board itself isn't
+// modified.
+func Grade(board *Board) (Difficulty, Report) {
+	report := Report{Techniques: map[EventKind]int{}}
+	difficulty := DifficultyEasy
+	step := func(ev Event) {
+		report.Steps = append(report.Steps, ev)
+		report.Techniques[ev.Kind]++
+		if techniqueDifficulty[ev.Kind] > difficulty {
+			difficulty = techniqueDifficulty[ev.Kind]
+		}
+	}
+
+	if err := board.checkArea(); err != nil {
+		report.Err = err
+		return DifficultyInvalid, report
+	}
+
+	bo := board.Clone()
+	for {
+		hint, err := bo.NextHint()
+		if err == ErrSolved {
+			return difficulty, report
+		} else if err == ErrNoDeduction {
+			break
+		} else if err != nil {
+			report.Err = err
+			return DifficultyInvalid, report
+		}
+
+		for _, r := range hint.Probed {
+			step(Event{Kind: EventProbe, Pos: r.Given, Rect: r})
+		}
+		step(Event{Kind: hint.Kind, Pos: hint.Pos, Rect: hint.Rect})
+		if hint.Kind == EventOverlap {
+			bo.Own(hint.Rect)
+		} else {
+			bo.Finalize(hint.Rect)
+		}
+	}
+
+	// Guess the rest.
+	report.Backtracking = true
+	stats, err := bo.SolveContext(context.Background(), SolveOptions{
+		Observer: ObserverFunc(func(ev Event) {
+			if ev.Kind != EventCandidates {
+				step(ev)
+			}
+		}),
+	})
+	report.Search = stats
+	if err != nil {
+		report.Err = err
+		return DifficultyInvalid, report
+	}
+	return difficulty, report
+}
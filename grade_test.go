@@ -0,0 +1,78 @@
+package shikaku
+
+import "testing"
+
+func TestGrade(t *testing.T) {
+	type testCase struct {
+		Board        string
+		Difficulty   Difficulty
+		Backtracking bool
+	}
+
+	tests := []testCase{
+		{testBoards[0], DifficultyEasy, false},
+		{testBoards[2], DifficultyEasy, false},
+		{testBoards[4], DifficultyMedium, false},
+		{testBoards[3], DifficultyExpert, true},
+		{testOverlapBoard, DifficultyHard, false},
+		{testBranchBoard, DifficultyHard, false},
+		{testBadBoards[0], DifficultyInvalid, false},
+	}
+
+	for _, test := range tests {
+		bo, _ := NewBoardFromString(test.Board)
+		origStr := bo.DebugString()
+
+		difficulty, report := Grade(bo)
+		if difficulty != test.Difficulty || report.Backtracking != test.Backtracking {
+			t.Errorf("Graded %v (backtracking %v), expected %v (backtracking %v)",
+				difficulty, report.Backtracking, test.Difficulty, test.Backtracking)
+			t.Log("\n" + bo.String())
+		}
+
+		if (difficulty == DifficultyInvalid) != (report.Err != nil) {
+			t.Errorf("Graded %v, with error %v", difficulty, report.Err)
+		}
+
+		count := 0
+		for _, n := range report.Techniques {
+			count += n
+		}
+		if count != len(report.Steps) {
+			t.Errorf("Counted %d techniques, but took %d steps", count, len(report.Steps))
+		}
+
+		if bo.DebugString() != origStr {
+			t.Error("Grading modified the board")
+		}
+	}
+}
+
+func TestGradeProbe(t *testing.T) {
+	bo, _ := NewBoardFromString(testBranchBoard)
+	solved := bo.Clone()
+	solved.Solve()
+
+	difficulty, report := Grade(bo)
+	if difficulty != DifficultyHard || report.Backtracking {
+		t.Errorf("Graded %v (backtracking %v), expected Hard without backtracking", difficulty, report.Backtracking)
+	}
+	if report.Techniques[EventProbe] == 0 {
+		t.Fatal("No Probe steps")
+	}
+
+	// Probe steps are Rects ruled out, as in a traced solve, and what's
+	// then forced is a separate step.
+	for _, ev := range report.Steps {
+		switch ev.Kind {
+		case EventProbe:
+			if solved.Get(ev.Pos).Final == ev.Rect {
+				t.Errorf("%v ruled out a Rect in the solution", ev)
+			}
+		case EventGivenForced, EventBlankForced:
+			if solved.Get(ev.Rect.Given).Final != ev.Rect {
+				t.Errorf("%v forced a Rect not in the solution", ev)
+			}
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package shikaku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoDeduction is returned by NextHint when nothing more can be deduced,
+// and the next step would have to be a guess.
+var ErrNoDeduction = errors.New("nothing more can be deduced without guessing")
+
+// ErrSolved is returned by NextHint when the board is already solved.
+var ErrSolved = errors.New("board is already solved")
+
+// Hint is a single logical step towards solving a board.
+type Hint struct {
+	// Kind is how the step was deduced: EventGivenForced, EventBlankForced,
+	// or EventOverlap.
+	Kind EventKind
+
+	// Pos is the given or blank the deduction is about.
+	Pos Vec2
+
+	// Rect is the Rect which the deduction shows must be final, or for
+	// EventOverlap, the squares it shows must be owned by the given.
+	Rect Rect
+
+	// Probed holds the Rects which had to be ruled out by probing first,
+	// because they lead straight to a contradiction, for the given or blank
+	// to be left with Rect as its only possibility.
+	Probed []Rect
+
+	// Reason explains the deduction, for people.
+	Reason string
+}
+
+// String returns the Hint's Reason.
+func (h Hint) String() string {
+	return h.Reason
+}
+
+// NextHint finds the next Rect which can be finalized by pure deduction,
+// without solving the rest of the board or modifying it. Givens with only one
+// possible Rect are found first, then blanks which only one Rect can cover,
+// then squares every possible Rect for a given covers, and last, givens and
+// blanks left with one Rect once those leading straight to a contradiction are
+// ruled out, as listed in the Hint's Probed.
+//
+// If the next step would have to be a guess, NextHint returns ErrNoDeduction,
+// and if the board is already solved, it returns ErrSolved. It returns another
+// error if the board can't be solved from where it is.
+func (bo *Board) NextHint() (Hint, error) {
+	bo = bo.Clone()
+	bo.IterWhere(IsNotFinal, func(pos Vec2, sq *Square) bool {
+		sq.Possible = sq.Possible[:0]
+		return true
+	})
+
+	var hint *Hint
+	var err error
+	solved := true
+
+	// Givens with one possible Rect
+	bo.IterWhere(IsUnsolvedGiven, func(pos Vec2, giv *Square) bool {
+		solved = false
+		possible := bo.Candidates(pos)
+		if len(possible) == 0 {
+			err = fmt.Errorf("the %d at %v can't be enclosed by anything", giv.Area, pos)
+			return false
+		} else if len(possible) == 1 && hint == nil {
+			hint = &Hint{
+				Kind:   EventGivenForced,
+				Pos:    pos,
+				Rect:   possible[0],
+				Reason: fmt.Sprintf("only placement for the %d at %v", giv.Area, pos),
+			}
+		}
+
+		for _, r := range possible {
+			bo.IterIn(r.A, r.B, func(pos Vec2, potential *Square) bool {
+				if potential != giv {
+					potential.AddPossible(r)
+				}
+				return true
+			})
+		}
+		return true
+	})
+
+	if err != nil {
+		return Hint{}, err
+	} else if hint != nil {
+		return *hint, nil
+	}
+
+	// Blanks with one possible Rect
+	bo.IterWhere(IsNotFinal, func(pos Vec2, blank *Square) bool {
+		solved = false
+		if len(blank.Possible) == 0 {
+			err = fmt.Errorf("cell %v can't be covered by anything", pos)
+			return false
+		} else if len(blank.Possible) == 1 && hint == nil {
+			r := blank.Possible[0]
+			hint = &Hint{
+				Kind:   EventBlankForced,
+				Pos:    pos,
+				Rect:   r,
+				Reason: fmt.Sprintf("cell %v can only be covered by the %d at %v", pos, bo.Get(r.Given).Area, r.Given),
+			}
+		}
+		return true
+	})
+
+	if err != nil {
+		return Hint{}, err
+	} else if hint != nil {
+		return *hint, nil
+	} else if solved {
+		return Hint{}, ErrSolved
+	}
+
+	// Squares covered by every possible Rect for a given, which another
+	// given could otherwise cover
+	bo.IterWhere(IsUnsolvedGiven, func(pos Vec2, giv *Square) bool {
+		possible := bo.Candidates(pos)
+		overlap := Rect{ORIGIN, bo.Size(), pos}
+		for _, r := range possible {
+			overlap.A, overlap.B = max2(overlap.A, r.A), min2(overlap.B, r.B)
+		}
+
+		bo.IterIn(overlap.A, overlap.B, func(_ Vec2, sq *Square) bool {
+			for _, r := range sq.Possible {
+				if r.Given != pos && IsNotFinal(*sq) {
+					hint = &Hint{
+						Kind:   EventOverlap,
+						Pos:    pos,
+						Rect:   overlap,
+						Reason: fmt.Sprintf("every placement for the %d at %v covers %v-%v", giv.Area, pos, overlap.A, overlap.B),
+					}
+					return false
+				}
+			}
+			return true
+		})
+		return hint == nil
+	})
+
+	if hint != nil {
+		return *hint, nil
+	}
+	return bo.probeHint()
+}
+
+// probeHint finds a given, or failing that a blank, with only one possible
+// Rect which doesn't lead straight to a contradiction. bo must have nothing
+// left to deduce any other way.
+func (bo *Board) probeHint() (Hint, error) {
+	st := newState(bo)
+	s := newSolver(context.Background(), SolveOptions{NoProbing: true})
+	if _, err := s.propagate(st, 0); err != nil {
+		return Hint{}, err
+	}
+	failed, err := s.probeAll(st)
+	if err != nil {
+		return Hint{}, err
+	}
+	for _, id := range failed {
+		st.kill(id)
+	}
+
+	// probed returns the failed Rects which pick matches.
+	probed := func(pick func(r Rect) bool) []Rect {
+		rects := []Rect{}
+		for _, id := range failed {
+			if pick(st.cands[id]) {
+				rects = append(rects, st.cands[id])
+			}
+		}
+		return rects
+	}
+
+	for g, pos := range st.givens {
+		if st.placed[g] < 0 && st.givenLive[g] == 1 {
+			return Hint{
+				Kind:   EventGivenForced,
+				Pos:    pos,
+				Rect:   st.cands[st.live.nextIn(st.first[g], st.first[g+1])],
+				Probed: probed(func(r Rect) bool { return r.Given == pos }),
+				Reason: fmt.Sprintf("every other placement for the %d at %v leads to a contradiction", bo.Get(pos).Area, pos),
+			}, nil
+		}
+	}
+	for c := range st.cellCands {
+		if !st.isFinal(c) && st.cellLive[c] == 1 {
+			pos, r := st.pos(c), st.cands[st.firstLiveIn(c)]
+			return Hint{
+				Kind:   EventBlankForced,
+				Pos:    pos,
+				Rect:   r,
+				Probed: probed(func(r Rect) bool { return pos.In(r.A, r.B) }),
+				Reason: fmt.Sprintf("cell %v can only be covered by the %d at %v without a contradiction", pos, bo.Get(r.Given).Area, r.Given),
+			}, nil
+		}
+	}
+	return Hint{}, ErrNoDeduction
+}
@@ -0,0 +1,121 @@
+package shikaku
+
+import "testing"
+
+func TestNextHint(t *testing.T) {
+	// These boards can be solved without guessing.
+	for _, i := range []int{0, 1, 2, 4, 5, 6} {
+		t.Run("Board", func(t *testing.T) {
+			bo, _ := NewBoardFromString(testBoards[i])
+			solved := bo.Clone()
+			solved.Solve()
+
+			for steps := 0; ; steps++ {
+				before := bo.DebugString()
+				hint, err := bo.NextHint()
+				if err == ErrSolved {
+					break
+				} else if err != nil {
+					t.Fatalf("Couldn't find a hint after %d steps: %v", steps, err)
+				}
+
+				if bo.DebugString() != before {
+					t.Fatal("NextHint() modified the board")
+				}
+				if solved.Get(hint.Pos).Final != hint.Rect {
+					t.Fatalf("Hint %v doesn't match the solution", hint)
+				}
+				bo.Finalize(hint.Rect)
+			}
+
+			if bo.String() != solved.String() {
+				t.Error("Following the hints didn't solve the board")
+				t.Log("Expected:\n" + solved.String())
+				t.Log("Actual:\n" + bo.String())
+			}
+		})
+	}
+}
+
+func TestNextHintReason(t *testing.T) {
+	// Nothing else fits the 5 in the bottom row.
+	bo, _ := NewBoardFromString(testBoards[0])
+	hint, err := bo.NextHint()
+	if err != nil {
+		t.Fatal("Couldn't find a hint:", err)
+	}
+	if hint.Kind != EventGivenForced || hint.String() != "only placement for the 5 at [1,4]" {
+		t.Errorf("Wrong hint: %v", hint)
+	}
+
+	// Every given fits more than one way, but only the bottom 3 can reach [1,3].
+	bo, _ = NewBoardFromString(`
+		-- 06 --
+		-- -- --
+		-- -- 03
+		03 -- --`)
+	hint, err = bo.NextHint()
+	if err != nil {
+		t.Fatal("Couldn't find a hint:", err)
+	}
+	if hint.Kind != EventBlankForced || hint.String() != "cell [1,3] can only be covered by the 3 at [0,3]" {
+		t.Errorf("Wrong hint: %v", hint)
+	}
+}
+
+func TestNextHintGuess(t *testing.T) {
+	bo, _ := NewBoardFromString(testBoards[3])
+
+	for {
+		hint, err := bo.NextHint()
+		if err == ErrNoDeduction {
+			break
+		} else if err != nil {
+			t.Fatal("Expected ErrNoDeduction, got", err)
+		}
+		if hint.Kind == EventOverlap {
+			bo.Own(hint.Rect)
+		} else {
+			bo.Finalize(hint.Rect)
+		}
+	}
+
+	if _, err := bo.NextHint(); err != ErrNoDeduction {
+		t.Error("Expected ErrNoDeduction again, got", err)
+	}
+}
+
+func TestNextHintProbe(t *testing.T) {
+	bo, _ := NewBoardFromString(testBranchBoard)
+	solved := bo.Clone()
+	solved.Solve()
+
+	probes := 0
+	for {
+		hint, err := bo.NextHint()
+		if err == ErrSolved {
+			break
+		} else if err != nil {
+			t.Fatal("Expected to solve the board with hints, got", err)
+		}
+
+		if hint.Kind == EventOverlap {
+			bo.Own(hint.Rect)
+			continue
+		}
+		for _, r := range hint.Probed {
+			probes++
+			if solved.Get(r.Given).Final == r {
+				t.Errorf("Hint %q ruled out %v, which is in the solution", hint, r)
+			}
+		}
+		if want := solved.Get(hint.Rect.Given).Final; hint.Rect != want {
+			t.Errorf("Hint %q placed %v, expected %v", hint, hint.Rect, want)
+		}
+		bo.Finalize(hint.Rect)
+	}
+
+	if probes == 0 {
+		t.Error("Solved without probing")
+	}
+}
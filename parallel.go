@@ -0,0 +1,96 @@
+package shikaku
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// branchParallel tries each of the possible candidates covering pos, like the
+// serial search in walk, but hands each guess to another goroutine whenever
+// a worker is free. As soon as one guess leads to a solution, the rest are
+// canceled, and the solution is placed on st.
+func (s *solver) branchParallel(st *state, pos Vec2, possible []int, depth int) error {
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	child := &solver{ctx: ctx, shared: s.shared, region: s.region}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		solution *state
+		abortErr error
+	)
+
+	// finish records the result of searching one guess, which had the
+	// given hash before it was searched.
+	finish := func(newState *state, poss int, hash uint64, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if solution != nil {
+			return // Another guess already won, so this one was canceled.
+		} else if err == nil {
+			solution = newState
+			cancel()
+		} else if isAbort(err) {
+			if abortErr == nil {
+				abortErr = err
+				cancel()
+			}
+		} else {
+			s.remember(hash)
+			s.emit(Event{Kind: EventBranchFailed, Pos: pos, Rect: st.cands[poss], Depth: depth})
+		}
+	}
+
+	for _, poss := range possible {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := s.node(); err != nil {
+			finish(nil, poss, 0, err)
+			break
+		}
+
+		s.emit(Event{Kind: EventBranch, Pos: pos, Rect: st.cands[poss], Depth: depth})
+		atomic.AddInt64(&s.guessed, 1)
+		newState := st.clone()
+		newState.place(poss)
+		if s.failed(newState) {
+			s.emit(Event{Kind: EventBranchFailed, Pos: pos, Rect: st.cands[poss], Depth: depth})
+			continue
+		}
+		hash := newState.hash
+
+		select {
+		case s.workers <- struct{}{}:
+			wg.Add(1)
+			go func(newState *state, poss int, hash uint64) {
+				defer func() {
+					<-s.workers
+					wg.Done()
+				}()
+				finish(newState, poss, hash, newWalk(child, newState, depth+1).run())
+			}(newState, poss, hash)
+		default:
+			// Every worker's busy, so search this one here.
+			finish(newState, poss, hash, newWalk(child, newState, depth+1).run())
+		}
+	}
+	wg.Wait()
+
+	if solution != nil {
+		st.adopt(solution, nil)
+		return nil
+	} else if abortErr != nil {
+		return abortErr
+	} else if err := s.check(); err != nil {
+		return err
+	}
+
+	s.emit(Event{Kind: EventBacktrack, Pos: pos, Depth: depth})
+	atomic.AddInt64(&s.backtracks, 1)
+	return errors.New("no possible solutions work")
+}
@@ -0,0 +1,51 @@
+package shikaku
+
+import "sync/atomic"
+
+// prober returns a solver to propagate probes with. It shares nothing with s
+// but its context and region, so probes don't emit events or count towards
+// the stats, and it doesn't probe itself.
+func (s *solver) prober() *solver {
+	return &solver{
+		ctx:    s.ctx,
+		shared: &shared{opts: SolveOptions{NoProbing: true}, start: s.start, runStart: s.runStart, ran: s.ran},
+		region: s.region,
+	}
+}
+
+// probe places candidate id on st and propagates from there, with p from
+// s.prober, returning true if that leads straight to a contradiction. st is
+// left as it was, so it mustn't have anything queued to be looked at.
+func (s *solver) probe(p *solver, st *state, id int) (failed bool, err error) {
+	mark := len(st.trail)
+	st.place(id)
+	_, err = p.propagate(st, 0)
+	st.undo(mark)
+
+	atomic.AddInt64(&s.checks, atomic.SwapInt64(&p.checks, 0))
+	if isAbort(err) {
+		return false, err
+	}
+	return err != nil, nil
+}
+
+// probeAll probes every live candidate of the unsolved givens in s's region,
+// returning those which lead straight to a contradiction. Each is probed on
+// st as it is, so they can all be ruled out together.
+func (s *solver) probeAll(st *state) (failed []int, err error) {
+	p := s.prober()
+	for g, pos := range st.givens {
+		if st.placed[g] >= 0 || (s.region != nil && !s.region.has(st.cell(pos))) {
+			continue
+		}
+		for id := st.live.nextIn(st.first[g], st.first[g+1]); id >= 0; id = st.live.nextIn(id+1, st.first[g+1]) {
+			contradiction, err := s.probe(p, st, id)
+			if err != nil {
+				return nil, err
+			} else if contradiction {
+				failed = append(failed, id)
+			}
+		}
+	}
+	return failed, nil
+}
@@ -0,0 +1,56 @@
+package shikaku
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestProbing(t *testing.T) {
+	for _, boString := range []string{testBranchBoard, testGuessBoard, testRegionsBoard} {
+		bo, _ := NewBoardFromString(boString)
+		guessed := bo.Clone()
+		if _, err := guessed.SolveContext(context.Background(), SolveOptions{NoProbing: true}); err != nil {
+			t.Fatal("Couldn't find solution to solvable puzzle:", err)
+		}
+
+		// Every one of these needs guessing without probing, and none with it.
+		stats, err := bo.SolveContext(context.Background(), SolveOptions{})
+		if err != nil {
+			t.Fatal("Couldn't find solution to solvable puzzle:", err)
+		}
+		if stats.Guessed != 0 || stats.Probed == 0 {
+			t.Errorf("Guessed %d times after probing %d Rects, expected no guesses", stats.Guessed, stats.Probed)
+		}
+		if bo.String() != guessed.String() {
+			t.Error("Solution differs from the one found guessing")
+			t.Log("Expected:\n" + guessed.String())
+			t.Log("Actual:\n" + bo.String())
+		}
+	}
+}
+
+func TestProbeUndo(t *testing.T) {
+	bo, _ := NewBoardFromString(testGuessBoard)
+	st := newState(bo)
+	s := newSolver(context.Background(), SolveOptions{NoProbing: true})
+	if solved, err := s.propagate(st, 0); solved || err != nil {
+		t.Fatal("Expected to have to guess, got", solved, err)
+	}
+	before, beforeStats := st.clone(), s.stats()
+
+	// Probing leaves the state as it was.
+	failed, err := s.probeAll(st)
+	if err != nil {
+		t.Fatal("Couldn't probe:", err)
+	}
+	if len(failed) == 0 {
+		t.Error("No probes failed")
+	}
+	if stats := s.stats(); stats.Passes != beforeStats.Passes || stats.Forced != beforeStats.Forced {
+		t.Errorf("Probes counted %d passes and %d forced Rects", stats.Passes-beforeStats.Passes, stats.Forced-beforeStats.Forced)
+	}
+	if !reflect.DeepEqual(st.clone(), before) {
+		t.Error("Probing didn't restore the state")
+	}
+}
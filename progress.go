@@ -0,0 +1,74 @@
+package shikaku
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DefaultProgressInterval is the least time between calls to
+// SolveOptions.Progress, if no ProgressInterval is given.
+const DefaultProgressInterval = 100 * time.Millisecond
+
+// Progress is how far a solve has got, as reported to SolveOptions.Progress.
+type Progress struct {
+	// Finalized is the fraction of the board's squares which are final, from
+	// 0 to 1, on the board being searched. It goes down again whenever a
+	// guess has to be undone.
+	Finalized float64
+
+	// Depth is how many guesses deep the search currently is.
+	Depth int
+
+	// NodesPerSecond is how many search nodes have been explored each
+	// second, on average, since the solve started.
+	NodesPerSecond float64
+
+	// Elapsed is how long the solve has been going.
+	Elapsed time.Duration
+}
+
+// progressInterval returns the least time between calls to Progress.
+func (s *solver) progressInterval() time.Duration {
+	if s.opts.ProgressInterval > 0 {
+		return s.opts.ProgressInterval
+	}
+	return DefaultProgressInterval
+}
+
+// progress reports how far the search has got to Progress, with st depth
+// guesses in, if it's been long enough since it was last reported.
+func (s *solver) progress(st *state, depth int) {
+	if s.opts.Progress == nil {
+		return
+	}
+
+	// Only whichever goroutine gets to move the next report on makes this
+	// one.
+	elapsed := s.elapsed()
+	next := atomic.LoadInt64(&s.nextProgress)
+	if int64(elapsed) < next || !atomic.CompareAndSwapInt64(&s.nextProgress, next, int64(elapsed+s.progressInterval())) {
+		return
+	}
+	s.report(st, depth, elapsed)
+}
+
+// report reports how far the search has got to Progress, with st depth
+// guesses in, after elapsed.
+func (s *solver) report(st *state, depth int, elapsed time.Duration) {
+	if s.opts.Progress == nil {
+		return
+	}
+
+	p := Progress{
+		Finalized: float64(st.w*st.h-st.remaining) / float64(st.w*st.h),
+		Depth:     depth,
+		Elapsed:   elapsed,
+	}
+	if elapsed > 0 {
+		p.NodesPerSecond = float64(atomic.LoadInt64(&s.nodes)) / elapsed.Seconds()
+	}
+
+	s.observerMu.Lock()
+	defer s.observerMu.Unlock()
+	s.opts.Progress(p)
+}
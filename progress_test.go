@@ -0,0 +1,111 @@
+package shikaku
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	bo, _ := NewBoardFromString(testTiledBoard(1, 30, 30, 6))
+	for _, workers := range []int{1, 4} {
+		reports := []Progress{}
+		stats, err := bo.Clone().SolveContext(context.Background(), SolveOptions{
+			Workers:          workers,
+			ProgressInterval: time.Nanosecond,
+			Progress: func(p Progress) {
+				reports = append(reports, p)
+			},
+		})
+		if err != nil {
+			t.Fatal("Couldn't find solution to solvable puzzle:", err)
+		}
+
+		if len(reports) < 2 {
+			t.Fatalf("With %d workers, reported progress %d times", workers, len(reports))
+		}
+		for _, p := range reports {
+			if p.Finalized < 0 || p.Finalized > 1 {
+				t.Errorf("Reported %v of the board finalized", p.Finalized)
+			}
+			if p.Depth > stats.MaxDepth {
+				t.Errorf("Reported depth %d, deeper than %d", p.Depth, stats.MaxDepth)
+			}
+			if p.NodesPerSecond <= 0 {
+				t.Errorf("Reported %v nodes per second", p.NodesPerSecond)
+			}
+			if p.Elapsed <= 0 || p.Elapsed > stats.Duration {
+				t.Errorf("Reported %v elapsed, in a %v solve", p.Elapsed, stats.Duration)
+			}
+		}
+		if last := reports[len(reports)-1]; last.Finalized != 1 {
+			t.Errorf("Finished with %v of the board finalized", last.Finalized)
+		}
+	}
+}
+
+func TestProgressInterval(t *testing.T) {
+	bo, _ := NewBoardFromString(testTiledBoard(1, 30, 30, 6))
+	reports := 0
+	_, err := bo.SolveContext(context.Background(), SolveOptions{
+		ProgressInterval: time.Hour,
+		Progress: func(p Progress) {
+			reports++
+		},
+	})
+	if err != nil {
+		t.Fatal("Couldn't find solution to solvable puzzle:", err)
+	}
+
+	// Only once it's solved.
+	if reports != 1 {
+		t.Errorf("Reported progress %d times within the interval", reports)
+	}
+}
+
+func TestProgressPaused(t *testing.T) {
+	bo, _ := NewBoardFromString(testGuessBoard)
+
+	// Pause after every guess, for longer than the whole solve takes.
+	var cancel context.CancelFunc
+	reports := []Progress{}
+	se, err := bo.NewSearch(SolveOptions{
+		NoProbing:        true,
+		ProgressInterval: time.Nanosecond,
+		Progress: func(p Progress) {
+			reports = append(reports, p)
+		},
+		Observer: ObserverFunc(func(ev Event) {
+			if ev.Kind == EventBranch {
+				cancel()
+			}
+		}),
+	})
+	if err != nil {
+		t.Fatal("Couldn't start search:", err)
+	}
+	for {
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		_, err = se.Run(ctx)
+		cancel()
+		if err != ErrCanceled {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal("Couldn't find solution to solvable puzzle:", err)
+	}
+
+	// Time spent paused isn't counted.
+	stats := se.Stats()
+	if stats.Duration >= 10*time.Millisecond {
+		t.Fatalf("Solve took %v, counting the pauses", stats.Duration)
+	}
+	for _, p := range reports {
+		if p.Elapsed > stats.Duration {
+			t.Errorf("Reported %v elapsed, in a %v solve", p.Elapsed, stats.Duration)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package shikaku
+
+import "context"
+
+// Propagate finalizes everything on the board which can be deduced without
+// guessing, the same way Solve does before it has to guess, and returns the
+// number of squares finalized. Each square left unfinalized has its Possible
+// list set to the Rects which could still cover it, and is owned by a given if
+// it's known to be covered by one.
+//
+// If the board turns out to be unsolvable, it's left as it was.
+func (bo *Board) Propagate() (progress int, err error) {
+	if err := bo.checkArea(); err != nil {
+		return 0, err
+	}
+
+	st := newState(bo)
+	s := newSolver(context.Background(), SolveOptions{})
+	if _, err := s.propagate(st, 0); err != nil {
+		return 0, explain(context.Background(), bo, err)
+	}
+
+	for _, r := range st.rects() {
+		progress += bo.Finalize(r)
+	}
+
+	for c := range st.cellCands {
+		if st.isFinal(c) {
+			continue
+		}
+		sq := bo.Get(st.pos(c))
+		if g := st.owner[c]; g >= 0 {
+			sq.Owner, sq.Owned = st.givens[g], true
+		}
+		sq.Possible = sq.Possible[:0]
+		for _, id := range st.liveIn(c) {
+			sq.Possible = append(sq.Possible, st.cands[id])
+		}
+	}
+	return progress, nil
+}
@@ -0,0 +1,110 @@
+package shikaku
+
+import "testing"
+
+func TestPropagate(t *testing.T) {
+	// Every board but the third can be solved without guessing.
+	for i, boString := range testBoards {
+		bo, _ := NewBoardFromString(boString)
+		solved := bo.Clone()
+		solved.Solve()
+
+		unfinal := 0
+		bo.IterWhere(IsNotFinal, func(pos Vec2, sq *Square) bool {
+			unfinal++
+			return true
+		})
+
+		progress, err := bo.Propagate()
+		if err != nil {
+			t.Fatal("Couldn't propagate a solvable board:", err)
+		}
+
+		guess := i == 3
+		if guess != (progress < unfinal) {
+			t.Errorf("Board %d: finalized %d of %d squares", i, progress, unfinal)
+		}
+		if !guess && bo.String() != solved.String() {
+			t.Errorf("Board %d: propagation didn't solve the board", i)
+			t.Log("Expected:\n" + solved.String())
+			t.Log("Actual:\n" + bo.String())
+		}
+	}
+}
+
+func TestPropagatePartial(t *testing.T) {
+	bo, _ := NewBoardFromString(testGuessBoard)
+	solved := bo.Clone()
+	solved.Solve()
+
+	progress, err := bo.Propagate()
+	if err != nil {
+		t.Fatal("Couldn't propagate a solvable board:", err)
+	}
+	if progress == 0 {
+		t.Error("Didn't finalize anything")
+	}
+	t.Log(progress, "squares finalized\n"+bo.String())
+
+	bo.Iter(func(pos Vec2, sq *Square) bool {
+		want := solved.Get(pos).Final
+		if IsFinal(*sq) {
+			if !IsGiven(*sq) && sq.Final != want {
+				t.Errorf("%v was finalized to %v, but is %v in the solution", pos, sq.Final, want)
+			}
+			return true
+		}
+
+		if IsOwned(*sq) && sq.Owner != want.Given {
+			t.Errorf("%v is owned by %v, but is covered by %v in the solution", pos, sq.Owner, want.Given)
+		}
+		if len(sq.Possible) < 2 {
+			t.Errorf("%v was left with Possible %v, which should have been deduced", pos, sq.Possible)
+		}
+		found := false
+		for _, r := range sq.Possible {
+			found = found || r == want
+		}
+		if !found {
+			t.Errorf("%v's Possible %v doesn't include %v from the solution", pos, sq.Possible, want)
+		}
+		return true
+	})
+}
+
+func TestPropagateBad(t *testing.T) {
+	for _, boString := range testBadBoards {
+		bo, _ := NewBoardFromString(boString)
+		origStr := bo.DebugString()
+
+		if _, err := bo.Propagate(); err == nil {
+			t.Error("Propagated a bad board without an error")
+		}
+		if bo.DebugString() != origStr {
+			t.Error("Propagating a bad board modified it")
+		}
+	}
+}
+
+func TestPropagateOwned(t *testing.T) {
+	// The 6 at [0,0] covers [0,1] however it's placed, but the board has
+	// more than one solution, so nothing more can be deduced.
+	bo, _ := NewBoardFromString(`
+		06 -- -- 03
+		-- -- -- --
+		-- -- 03 --
+		01 01 -- 02`)
+	if _, err := bo.Propagate(); err != nil {
+		t.Fatal("Couldn't propagate a solvable board:", err)
+	}
+
+	sq := bo.Get(Vec2{0, 1})
+	if !IsOwned(*sq) || sq.Owner != (Vec2{0, 0}) {
+		t.Errorf("[0,1] should be owned by [0,0]: %v", sq)
+	}
+	for _, r := range sq.Possible {
+		if r.Given != sq.Owner {
+			t.Errorf("[0,1] could be covered by %v, of another given", r)
+		}
+	}
+}
@@ -25,3 +25,9 @@ func (r Rect) Height() int {
 func (r Rect) Width() int {
 	return r.Size()[0]
 }
+
+// overlaps returns true if r and other have any squares in common.
+func (r Rect) overlaps(other Rect) bool {
+	a, b := max2(r.A, other.A), min2(r.B, other.B)
+	return a[0] < b[0] && a[1] < b[1]
+}
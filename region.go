@@ -0,0 +1,162 @@
+package shikaku
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// regions splits the cells which aren't final into independent regions: sets
+// of cells and unsolved givens which no live candidate joins together, so
+// what's placed in one can't affect another. Each is returned as a bitset of
+// its cells, including its givens' cells, smallest first.
+//
+// Only cells and givens in within are looked at, or every one if within is
+// nil.
+func (st *state) regions(within bitset) []bitset {
+	in := func(c int) bool {
+		return within == nil || within.has(c)
+	}
+
+	// Join the cells of each live candidate with union-find.
+	parent := make([]int32, len(st.cellCands))
+	for c := range parent {
+		parent[c] = int32(c)
+	}
+	var find func(c int32) int32
+	find = func(c int32) int32 {
+		if parent[c] != c {
+			parent[c] = find(parent[c])
+		}
+		return parent[c]
+	}
+
+	for g, pos := range st.givens {
+		if st.placed[g] >= 0 || !in(st.cell(pos)) {
+			continue
+		}
+		root := find(int32(st.cell(pos)))
+		for id := st.live.nextIn(st.first[g], st.first[g+1]); id >= 0; id = st.live.nextIn(id+1, st.first[g+1]) {
+			r := st.cands[id]
+			for y := r.A[1]; y < r.B[1]; y++ {
+				for x := r.A[0]; x < r.B[0]; x++ {
+					if other := find(int32(st.cell(Vec2{x, y}))); other != root {
+						parent[other] = root
+					}
+				}
+			}
+		}
+	}
+
+	// Every cell which isn't final is covered by a live candidate, so each
+	// region has at least one given.
+	index := map[int32]int{}
+	regions := []bitset{}
+	add := func(c int) {
+		root := find(int32(c))
+		i, ok := index[root]
+		if !ok {
+			i = len(regions)
+			index[root] = i
+			regions = append(regions, newBitset(len(st.cellCands)))
+		}
+		regions[i].set(c)
+	}
+	for g, pos := range st.givens {
+		if st.placed[g] < 0 && in(st.cell(pos)) {
+			add(st.cell(pos))
+		}
+	}
+	for c := range st.cellCands {
+		if !st.isFinal(c) && in(c) {
+			add(c)
+		}
+	}
+
+	sort.SliceStable(regions, func(i, j int) bool {
+		return regions[i].countIn(0, len(st.cellCands)) < regions[j].countIn(0, len(st.cellCands))
+	})
+	return regions
+}
+
+// unsolvedIn returns true if any cell in region isn't final.
+func (st *state) unsolvedIn(region bitset) bool {
+	for c := region.nextIn(0, len(st.cellCands)); c >= 0; c = region.nextIn(c+1, len(st.cellCands)) {
+		if !st.isFinal(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// solveRegions solves each of the independent regions of st on its own copy
+// of it in a parallel search, then places the Rects each found back on st.
+// Regions are handed to other goroutines whenever a worker is free, and solved
+// here otherwise. The smallest regions are solved first, and as soon as one of
+// them can't be, the rest are given up on.
+func (s *solver) solveRegions(st *state, regions []bitset, depth int) error {
+	atomic.AddInt64(&s.regions, int64(len(regions)))
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	// fail records the error one region couldn't be solved with, and stops
+	// the others.
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	solved := make([]*state, len(regions))
+	for i, region := range regions {
+		if ctx.Err() != nil {
+			break
+		}
+
+		sub := &solver{ctx: ctx, shared: s.shared, region: region}
+		solved[i] = st.clone()
+		run := func(sub *solver, subState *state) {
+			if err := newWalk(sub, subState, depth).run(); err != nil {
+				fail(err)
+			}
+		}
+
+		select {
+		case s.workers <- struct{}{}:
+			wg.Add(1)
+			go func(sub *solver, subState *state) {
+				defer func() {
+					<-s.workers
+					wg.Done()
+				}()
+				run(sub, subState)
+			}(sub, solved[i])
+		default:
+			// Every worker's busy, so solve this one here.
+			run(sub, solved[i])
+		}
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	} else if err := s.check(); err != nil {
+		return err
+	}
+
+	for i, region := range regions {
+		st.adopt(solved[i], region)
+	}
+	return nil
+}
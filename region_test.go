@@ -0,0 +1,69 @@
+package shikaku
+
+import (
+	"context"
+	"testing"
+)
+
+// testRegionsBoard is testBranchBoard twice, with a column of 1s keeping them
+// apart, so each half can be solved on its own. Without probing, each half
+// needs guessing.
+const testRegionsBoard = `
+	04 -- 02 -- 01 04 -- 02 --
+	-- -- 03 -- 01 -- -- 03 --
+	-- 03 -- -- 01 -- 03 -- --
+	-- -- -- 04 01 -- -- -- 04`
+
+func TestSolveRegions(t *testing.T) {
+	half, _ := NewBoardFromString(testBranchBoard)
+	if err := half.Solve(); err != nil {
+		t.Fatal("Couldn't find solution to solvable puzzle:", err)
+	}
+
+	for _, workers := range []int{0, 4} {
+		bo, _ := NewBoardFromString(testRegionsBoard)
+		stats, err := bo.SolveContext(context.Background(), SolveOptions{Workers: workers, NoProbing: true})
+		if err != nil {
+			t.Fatal("Couldn't find solution to solvable puzzle:", err)
+		}
+		if stats.Regions != 2 {
+			t.Errorf("Workers %d: split into %d regions, expected 2", workers, stats.Regions)
+		}
+
+		half.Iter(func(pos Vec2, sq *Square) bool {
+			for _, ofs := range []Vec2{{0, 0}, {5, 0}} {
+				got := bo.Get(pos.Add(ofs)).Final
+				want := Rect{sq.Final.A.Add(ofs), sq.Final.B.Add(ofs), sq.Final.Given.Add(ofs)}
+				if got != want {
+					t.Errorf("Workers %d: %v is covered by %v, expected %v", workers, pos.Add(ofs), got, want)
+				}
+			}
+			return true
+		})
+	}
+}
+
+func TestSolveRegionsFailFast(t *testing.T) {
+	// The left half can't be solved, which only a guess shows. The right
+	// half takes 13 nodes to solve on its own, none of which are needed.
+	bo, _ := NewBoardFromString(`
+		-- -- -- 04 01 -- -- -- -- -- 08 -- -- -- 03 -- -- 03 04
+		-- -- 06 -- 01 02 -- 06 -- -- -- -- -- -- -- -- -- -- --
+		-- 02 -- -- 01 -- -- -- 08 -- -- -- -- 06 -- 06 -- -- --
+		04 -- -- -- 01 -- -- -- -- 04 -- -- -- -- 03 -- 02 01 --`)
+
+	stats, err := bo.SolveContext(context.Background(), SolveOptions{NoProbing: true})
+	unsolvable, ok := err.(*UnsolvableError)
+	if !ok {
+		t.Fatal("Expected an UnsolvableError, got", err)
+	}
+	for _, pos := range unsolvable.Positions() {
+		if pos[0] >= 4 {
+			t.Errorf("%v isn't in the left half: %v", pos, err)
+		}
+	}
+
+	if stats.Regions != 2 || stats.Nodes > 5 {
+		t.Errorf("Explored %d nodes in %d regions, expected at most 5 in 2", stats.Nodes, stats.Regions)
+	}
+}
@@ -0,0 +1,317 @@
+package shikaku
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// frame is a guess on a walk's stack.
+type frame struct {
+	// mark is how long the state's trail was before the guess.
+	mark int
+
+	// pos is the position guessed at, and possible the candidates to try
+	// there, in order. next is the index in possible of the next one to try,
+	// so possible[next-1] is the one being tried.
+	pos      Vec2
+	possible []int
+	next     int
+
+	// hash is the hash of the state just after the guess being tried.
+	hash uint64
+
+	// region holds the cells guessed in, if the board had been split into
+	// independent regions, and regions is how many there were.
+	region  bitset
+	regions int
+}
+
+// walk is a depth-first search of a state. Rather than recursing, it keeps
+// its guesses on a stack, undoing each with the state's trail once it fails,
+// so it can be stopped and carried on with at any point.
+type walk struct {
+	*solver
+	st *state
+
+	// depth is the depth the walk started at, for walks of a branch.
+	depth int
+
+	stack []frame
+
+	// guess is true if the next thing to do is try the next guess at the
+	// top of the stack, rather than propagate.
+	guess bool
+
+	// restartAt is the number of search nodes after which the walk restarts,
+	// or 0 if it doesn't.
+	restartAt int64
+}
+
+// newWalk creates a walk of st, which is depth guesses in.
+func newWalk(s *solver, st *state, depth int) *walk {
+	return &walk{solver: s, st: st, depth: depth}
+}
+
+// run carries on the walk until st is solved, leaving the solution in it, or
+// it's shown to have no solution. If it's stopped early, by an error which
+// isAbort, it can be carried on with by calling run again.
+func (w *walk) run() error {
+	for {
+		if w.guess {
+			if err := w.next(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		solved, err := w.propagate(w.st, w.depth+len(w.stack))
+		w.progress(w.st, w.depth+len(w.stack))
+		if isAbort(err) {
+			return err
+		} else if err == nil && solved {
+			err = w.found(w.st)
+			if err == nil || isAbort(err) {
+				return err
+			}
+			// Otherwise, carry on looking for the next solution.
+		} else if err == nil {
+			err = w.branch()
+			if isAbort(err) {
+				return err
+			}
+		}
+
+		if err != nil {
+			if len(w.stack) == 0 {
+				return err
+			} else if err := w.backtrack(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// branch chooses where to guess, once nothing more can be deduced, and
+// pushes a frame for it. If the search is parallel, every guess is tried
+// there and then instead.
+func (w *walk) branch() error {
+	depth := w.depth + len(w.stack)
+
+	// If parts of the board can't affect each other, guess in each of them
+	// separately, rather than in all of them together. When looking for
+	// every solution, they'd have to be combined.
+	var regions []bitset
+	if w.visit == nil {
+		regions = w.st.regions(w.region)
+	}
+	if len(regions) > 1 && w.workers != nil {
+		return w.solveRegions(w.st, regions, depth)
+	}
+
+	f := frame{region: w.region, regions: len(regions)}
+	if len(regions) > 1 {
+		f.region = regions[0]
+
+		prev := 1
+		if len(w.stack) > 0 {
+			prev = w.stack[len(w.stack)-1].regions
+		}
+		if f.regions > prev {
+			atomic.AddInt64(&w.regions, int64(f.regions-prev+1))
+		}
+	}
+
+	// Something has to be enclosed by one of its candidates, so try each of
+	// them in turn.
+	f.pos, f.possible = w.solver.branch(w.st, f.region)
+	if w.prefer != nil {
+		sort.SliceStable(f.possible, func(i, j int) bool {
+			return w.prefer.has(f.possible[i]) && !w.prefer.has(f.possible[j])
+		})
+	}
+	if w.workers != nil && w.visit == nil {
+		return w.branchParallel(w.st, f.pos, f.possible, depth)
+	}
+
+	if len(w.stack) == 0 {
+		// There's never anything to undo past here.
+		w.st.trail = w.st.trail[:0]
+	}
+	f.mark = len(w.st.trail)
+	w.stack = append(w.stack, f)
+	w.guess = true
+	return nil
+}
+
+// next tries the next guess at the top of the stack.
+func (w *walk) next() error {
+	if w.restartAt > 0 && atomic.LoadInt64(&w.nodes) >= w.restartAt {
+		w.restart()
+		return nil
+	}
+	if err := w.node(); err != nil {
+		return err
+	}
+
+	f := &w.stack[len(w.stack)-1]
+	id := f.possible[f.next]
+	f.next++
+
+	w.emit(Event{Kind: EventBranch, Pos: f.pos, Rect: w.st.cands[id], Depth: w.depth + len(w.stack) - 1})
+	atomic.AddInt64(&w.guessed, 1)
+	w.st.place(id)
+	f.hash = w.st.hash
+
+	w.guess = false
+	if w.visit == nil && w.failed(w.st) {
+		return w.backtrack()
+	}
+	return nil
+}
+
+// backtrack undoes the guess at the top of the stack, which has failed. If it
+// was the last one to try there, the guess before it must be wrong too, and
+// so on. It returns an error if every guess has failed.
+func (w *walk) backtrack() error {
+	for len(w.stack) > 0 {
+		f := &w.stack[len(w.stack)-1]
+		depth := w.depth + len(w.stack) - 1
+
+		w.emit(Event{Kind: EventBranchFailed, Pos: f.pos, Rect: w.st.cands[f.possible[f.next-1]], Depth: depth})
+		if w.visit == nil {
+			w.remember(f.hash)
+		}
+		w.st.undo(f.mark)
+		if f.next < len(f.possible) {
+			w.guess = true
+			return nil
+		}
+
+		w.emit(Event{Kind: EventBacktrack, Pos: f.pos, Depth: depth})
+		atomic.AddInt64(&w.backtracks, 1)
+		w.stack = w.stack[:len(w.stack)-1]
+
+		// Guesses made in other regions can't have caused this, so skip
+		// back past them.
+		for f.region != nil && len(w.stack) > 0 {
+			top := w.stack[len(w.stack)-1]
+			if top.region == nil || top.region.intersects(f.region) {
+				break
+			}
+			w.stack = w.stack[:len(w.stack)-1]
+		}
+	}
+	return errors.New("no possible solutions work")
+}
+
+// restart undoes every guess, and chooses a new seed to guess again with. The
+// transposition table is kept, since what failed before still can't work.
+func (w *walk) restart() {
+	w.emit(Event{Kind: EventRestart, Depth: w.depth + len(w.stack)})
+	w.st.undo(w.stack[0].mark)
+	w.stack = w.stack[:0]
+	w.guess = false
+
+	restarts := atomic.AddInt64(&w.restarts, 1)
+	atomic.StoreInt64(&w.seed, int64(splitmix(uint64(w.seed))>>1))
+	w.restartAt = atomic.LoadInt64(&w.nodes) + int64(w.opts.RestartNodes*luby(int(restarts)+1))
+}
+
+// luby returns the ith number in the Luby sequence, 1, 1, 2, 1, 1, 2, 4, 1, 1,
+// 2, ..., counting from 1.
+func luby(i int) int {
+	// The sequence is made of runs ending in 1, 2, 4, ..., where the run
+	// ending in 2^(k-1) is 2^k-1 long, and repeats the ones before it.
+	for {
+		k := 1
+		for (1<<uint(k))-1 < i {
+			k++
+		}
+		if i == (1<<uint(k))-1 {
+			return 1 << uint(k-1)
+		}
+		i -= (1 << uint(k-1)) - 1
+	}
+}
+
+// Search is a solve of a board by the default solver, which can be paused and
+// resumed.
+type Search struct {
+	w       *walk
+	elapsed time.Duration
+
+	// done is true once the search has finished, with rects and err.
+	done  bool
+	rects []Rect
+	err   error
+}
+
+// NewSearch starts solving bo with opts, like SolveContext would with the
+// default solver. Nothing's searched until Run is called, and bo isn't
+// modified.
+func (bo *Board) NewSearch(opts SolveOptions) (*Search, error) {
+	if err := bo.checkArea(); err != nil {
+		return nil, err
+	}
+
+	s := newSolver(context.Background(), opts)
+	st := newState(bo)
+	s.candidates = int64(len(st.cands))
+	w := newWalk(s, st, 0)
+	if opts.Branching == BranchRandom && opts.RestartNodes > 0 && s.workers == nil {
+		// Counting the starting board, which is the first node.
+		w.restartAt = 1 + int64(opts.RestartNodes*luby(1))
+	}
+	return &Search{w: w, elapsed: time.Since(s.start)}, nil
+}
+
+// Run searches until it finds a solution, returning the Rect enclosing each
+// given in row-major order of their givens. If ctx is done first, it returns
+// ErrCanceled, and the search can be resumed by calling Run again. Once the
+// search has finished, Run returns the same result each time.
+func (se *Search) Run(ctx context.Context) ([]Rect, error) {
+	if se.done {
+		return se.rects, se.err
+	}
+
+	start := time.Now()
+	defer func() {
+		se.elapsed += time.Since(start)
+	}()
+
+	w := se.w
+	w.ctx = ctx
+	w.ran, w.runStart = se.elapsed, start
+	var err error
+	if atomic.LoadInt64(&w.nodes) == 0 {
+		// The starting board is the first node.
+		err = w.node()
+	}
+	if err == nil {
+		err = w.run()
+	}
+	if err == ErrCanceled {
+		return nil, err
+	} else if err != nil {
+		return nil, se.finish(err)
+	}
+	se.rects = w.st.rects()
+	w.report(w.st, len(w.stack), se.elapsed+time.Since(start))
+	return se.rects, se.finish(nil)
+}
+
+// finish records that the search has finished with err.
+func (se *Search) finish(err error) error {
+	se.done, se.err = true, err
+	return err
+}
+
+// Stats returns the work done by the search so far.
+func (se *Search) Stats() SolveStats {
+	stats := se.w.stats()
+	stats.Duration = se.elapsed
+	return stats
+}
@@ -0,0 +1,225 @@
+package shikaku
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"runtime/debug"
+	"testing"
+)
+
+// testTiledBoard returns a w by h board made by tiling it with random Rects
+// no bigger than maxSide on each side, so it has at least one solution.
+func testTiledBoard(seed int64, w, h, maxSide int) string {
+	r := rand.New(rand.NewSource(seed))
+	areas := make([][]int, h)
+	tiled := make([][]bool, h)
+	for y := range areas {
+		areas[y] = make([]int, w)
+		tiled[y] = make([]bool, w)
+	}
+
+	fits := func(x, y, rw, rh int) bool {
+		if x+rw > w || y+rh > h {
+			return false
+		}
+		for yy := y; yy < y+rh; yy++ {
+			for xx := x; xx < x+rw; xx++ {
+				if tiled[yy][xx] {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if tiled[y][x] {
+				continue
+			}
+			rw, rh := 1+r.Intn(maxSide), 1+r.Intn(maxSide)
+			for !fits(x, y, rw, rh) {
+				rw, rh = 1+r.Intn(rw), 1+r.Intn(rh)
+			}
+			for yy := y; yy < y+rh; yy++ {
+				for xx := x; xx < x+rw; xx++ {
+					tiled[yy][xx] = true
+				}
+			}
+			areas[y+r.Intn(rh)][x+r.Intn(rw)] = rw * rh
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, row := range areas {
+		for _, area := range row {
+			if area == 0 {
+				buf.WriteString("-- ")
+			} else {
+				fmt.Fprintf(&buf, "%02d ", area)
+			}
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+func TestSearchPause(t *testing.T) {
+	bo, _ := NewBoardFromString(testGuessBoard)
+	solved := bo.Clone()
+	want, err := solved.SolveContext(context.Background(), SolveOptions{NoProbing: true})
+	if err != nil {
+		t.Fatal("Couldn't find solution to solvable puzzle:", err)
+	}
+
+	// Pause after every guess.
+	var cancel context.CancelFunc
+	se, err := bo.NewSearch(SolveOptions{
+		NoProbing: true,
+		Observer: ObserverFunc(func(ev Event) {
+			if ev.Kind == EventBranch {
+				cancel()
+			}
+		}),
+	})
+	if err != nil {
+		t.Fatal("Couldn't start search:", err)
+	}
+
+	pauses := 0
+	var rects []Rect
+	for {
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		rects, err = se.Run(ctx)
+		cancel()
+		if err != ErrCanceled {
+			break
+		}
+		pauses++
+	}
+	if err != nil {
+		t.Fatal("Couldn't find solution to solvable puzzle:", err)
+	}
+
+	if pauses != want.Guessed {
+		t.Errorf("Paused %d times, expected once for each of %d guesses", pauses, want.Guessed)
+	}
+	if !reflect.DeepEqual(rects, solved.Rects()) {
+		t.Errorf("Found %v, expected %v", rects, solved.Rects())
+	}
+	if stats := se.Stats(); stats.Nodes != want.Nodes || stats.Backtracks != want.Backtracks {
+		t.Errorf("Explored %d nodes with %d backtracks, expected %d with %d",
+			stats.Nodes, stats.Backtracks, want.Nodes, want.Backtracks)
+	}
+
+	// It's finished, so running it again gives the same result.
+	if again, err := se.Run(context.Background()); err != nil || !reflect.DeepEqual(again, rects) {
+		t.Errorf("Ran again and got %v, %v", again, err)
+	}
+}
+
+func TestSearchUndo(t *testing.T) {
+	bo, _ := NewBoardFromString(testGuessBoard)
+	st := newState(bo)
+	s := newSolver(context.Background(), SolveOptions{NoProbing: true})
+	if solved, err := s.propagate(st, 0); solved || err != nil {
+		t.Fatal("Expected to have to guess, got", solved, err)
+	}
+	before := st.clone()
+
+	// Every guess, and everything deduced from it, is undone.
+	_, possible := s.branch(st, nil)
+	for _, id := range possible {
+		mark := len(st.trail)
+		st.place(id)
+		s.propagate(st, 1)
+		st.undo(mark)
+
+		after := st.clone()
+		if !reflect.DeepEqual(after, before) {
+			t.Errorf("Undoing %v didn't restore the state", st.cands[id])
+		}
+	}
+}
+
+func TestSearchLarge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping large board in short mode")
+	}
+
+	bo, _ := NewBoardFromString(testTiledBoard(1, 100, 100, 6))
+
+	// The search mustn't need more stack for bigger boards, or deeper
+	// searches.
+	defer debug.SetMaxStack(debug.SetMaxStack(64 << 10))
+
+	stats, err := bo.SolveContext(context.Background(), SolveOptions{})
+	if err != nil {
+		t.Fatal("Couldn't find solution to solvable puzzle:", err)
+	}
+	if stats.MaxDepth == 0 {
+		t.Error("Solved without guessing, so the stack wasn't tested")
+	}
+	if violations := CheckSolution(bo, bo.Rects()); len(violations) > 0 {
+		t.Error("Solution is wrong:", violations)
+	}
+	t.Logf("%+v", stats)
+}
+
+func TestLuby(t *testing.T) {
+	want := []int{1, 1, 2, 1, 1, 2, 4, 1, 1, 2, 1, 1, 2, 4, 8, 1}
+	for i, n := range want {
+		if got := luby(i + 1); got != n {
+			t.Errorf("luby(%d) = %d, expected %d", i+1, got, n)
+		}
+	}
+}
+
+func TestSearchRestarts(t *testing.T) {
+	// solve returns the solution and events of solving the large board with
+	// restarts.
+	bo, _ := NewBoardFromString(testTiledBoard(1, 30, 30, 6))
+	solve := func(seed int64) ([]Rect, []Event, SolveStats) {
+		events := []Event{}
+		solved := bo.Clone()
+		stats, err := solved.SolveContext(context.Background(), SolveOptions{
+			Branching:    BranchRandom,
+			Seed:         seed,
+			RestartNodes: 1,
+			Observer: ObserverFunc(func(ev Event) {
+				events = append(events, ev)
+			}),
+		})
+		if err != nil {
+			t.Fatal("Couldn't find solution to solvable puzzle:", err)
+		}
+		if violations := CheckSolution(bo, solved.Rects()); len(violations) > 0 {
+			t.Error("Solution is wrong:", violations)
+		}
+		return solved.Rects(), events, stats
+	}
+
+	rects, events, stats := solve(0)
+	if stats.Restarts == 0 {
+		t.Fatal("Never restarted")
+	}
+	restarts := 0
+	for _, ev := range events {
+		if ev.Kind == EventRestart {
+			restarts++
+		}
+	}
+	if restarts != stats.Restarts {
+		t.Errorf("%d Restart events, for %d restarts", restarts, stats.Restarts)
+	}
+
+	// The restarts happen in the same places with the same seed.
+	againRects, againEvents, againStats := solve(stats.Seed)
+	if !reflect.DeepEqual(againRects, rects) || !reflect.DeepEqual(againEvents, events) {
+		t.Errorf("Solving with seed %d again took %d restarts, expected %d", stats.Seed, againStats.Restarts, stats.Restarts)
+	}
+}
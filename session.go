@@ -0,0 +1,387 @@
+package shikaku
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// Session is a board which is being edited, and is solved again after each
+// edit. Rather than solving it from scratch each time, it keeps the
+// Candidates of each given which the edit didn't affect, makes again the
+// deductions of the last solve which still follow, and tries the Rects of the
+// previous solution first when it has to guess.
+type Session struct {
+	// board holds the givens, with the locked Rects final, and nothing else.
+	board *Board
+
+	// cands holds the Candidates of each unlocked given on board, for those
+	// which have been enumerated.
+	cands map[Vec2][]Rect
+
+	// deduced holds the steps deduced by the last solve before it guessed
+	// anything, in order.
+	deduced []Event
+
+	// solution is the current solution, or nil if there isn't one, and err
+	// is why not. If the search for it failed, failed is what with, and err
+	// is only found from it once it's asked for.
+	solution []Rect
+	failed   error
+	err      error
+
+	stats SolveStats
+}
+
+// Diff is the change to a Session's solution made by an edit.
+type Diff struct {
+	// Added holds the Rects in the new solution which weren't in the old
+	// one, and Removed those in the old one which aren't in the new one, each
+	// in row-major order of their givens. If there's no solution, every Rect
+	// of the old one is removed.
+	Added   []Rect
+	Removed []Rect
+}
+
+// NewSession starts a session editing a copy of bo's givens, and solves it.
+// The final Rects of any givens already solved on bo are locked.
+func NewSession(bo *Board) *Session {
+	board := &Board{Grid: make([][]Square, bo.Height())}
+	for y, row := range bo.Grid {
+		board.Grid[y] = make([]Square, len(row))
+		for x, sq := range row {
+			if IsGiven(sq) {
+				board.Grid[y][x] = NewGiven(sq.Area)
+			} else {
+				board.Grid[y][x] = NewBlank()
+			}
+		}
+	}
+	for _, r := range bo.Rects() {
+		board.Finalize(r)
+	}
+
+	se := &Session{board: board, cands: map[Vec2][]Rect{}}
+	se.solve()
+	return se
+}
+
+// Solution returns the Rect enclosing each given in the current solution, in
+// row-major order of their givens, or nil if the board can't be solved.
+func (se *Session) Solution() []Rect {
+	return se.solution
+}
+
+// Err returns why the board can't be solved, or nil if it can.
+func (se *Session) Err() error {
+	if se.err == nil && se.failed != nil {
+		se.err = explain(context.Background(), se.board, se.failed)
+	}
+	return se.err
+}
+
+// Stats returns the work done by the last solve.
+func (se *Session) Stats() SolveStats {
+	return se.stats
+}
+
+// Board returns a copy of the board, with the current solution final.
+func (se *Session) Board() *Board {
+	bo := se.board.Clone()
+	for _, r := range se.solution {
+		bo.Finalize(r)
+	}
+	return bo
+}
+
+// SetGiven makes the square at pos a given with area, or changes the area of
+// the given already there, unlocking it, then solves the board again. If pos
+// isn't on the board, area is less than 1, or the square is part of another
+// given's locked Rect, it returns an error without changing anything.
+func (se *Session) SetGiven(pos Vec2, area int) (Diff, error) {
+	if !pos.In(ORIGIN, se.board.Size()) {
+		return Diff{}, fmt.Errorf("Position %v isn't on the board", pos)
+	} else if area < 1 {
+		return Diff{}, errors.New("Area must be at least 1")
+	}
+
+	sq := se.board.Get(pos)
+	switch {
+	case IsGiven(*sq) && sq.Area == area:
+		return diffSolutions(se.solution, se.solution), nil
+	case IsGiven(*sq):
+		se.unlock(pos)
+		delete(se.cands, pos)
+	case IsFinal(*sq):
+		return Diff{}, fmt.Errorf("Square %v is locked by the given at %v", pos, sq.Final.Given)
+	default:
+		// Nothing else can cover the new given's square.
+		se.forget(Rect{pos, pos.Add(Vec2{1, 1}), pos})
+	}
+
+	*sq = NewGiven(area)
+	return se.solve(), nil
+}
+
+// ClearGiven makes the given at pos a blank square, unlocking it, then solves
+// the board again. If there's no given at pos, it returns an error without
+// changing anything.
+func (se *Session) ClearGiven(pos Vec2) (Diff, error) {
+	if !pos.In(ORIGIN, se.board.Size()) || !IsGiven(*se.board.Get(pos)) {
+		return Diff{}, fmt.Errorf("No given at %v", pos)
+	}
+
+	se.unlock(pos)
+	delete(se.cands, pos)
+	*se.board.Get(pos) = NewBlank()
+
+	// Other givens may be able to cover its square now.
+	se.invalidate(Rect{pos, pos.Add(Vec2{1, 1}), pos})
+	return se.solve(), nil
+}
+
+// Lock fixes r as the Rect enclosing its given, whatever the rest of the
+// board does, then solves the board again. If r isn't on the board, doesn't
+// enclose its given and no other, has the wrong area, or overlaps another
+// given's locked Rect, it returns an error without changing anything.
+func (se *Session) Lock(r Rect) (Diff, error) {
+	if !se.board.Contains(r) || r.Width() <= 0 || r.Height() <= 0 {
+		return Diff{}, fmt.Errorf("%v isn't on the board", r)
+	}
+
+	givens := 0
+	var problem error
+	se.board.IterIn(r.A, r.B, func(pos Vec2, sq *Square) bool {
+		if IsGiven(*sq) {
+			givens++
+		}
+		if sq.Final != (Rect{}) && sq.Final.Given != r.Given {
+			problem = fmt.Errorf("%v overlaps the locked %v", r, sq.Final)
+			return false
+		}
+		return true
+	})
+	if problem != nil {
+		return Diff{}, problem
+	} else if givens != 1 || !r.Given.In(r.A, r.B) || !IsGiven(*se.board.Get(r.Given)) {
+		return Diff{}, fmt.Errorf("%v doesn't enclose just the given at %v", r, r.Given)
+	} else if r.Width()*r.Height() != se.board.Get(r.Given).Area {
+		return Diff{}, fmt.Errorf("%v has the wrong area for the given at %v", r, r.Given)
+	} else if se.board.Get(r.Given).Final == r {
+		return diffSolutions(se.solution, se.solution), nil
+	}
+
+	se.unlock(r.Given)
+	se.board.Finalize(r)
+	delete(se.cands, r.Given)
+	se.forget(r)
+
+	// If r is already in the solution, the rest of it still works.
+	for _, sol := range se.solution {
+		if sol == r {
+			return diffSolutions(se.solution, se.solution), nil
+		}
+	}
+	return se.solve(), nil
+}
+
+// unlock unlocks the given at pos, if it's locked.
+func (se *Session) unlock(pos Vec2) {
+	r := se.board.Get(pos).Final
+	if r == (Rect{}) {
+		return
+	}
+	se.board.IterIn(r.A, r.B, func(_ Vec2, sq *Square) bool {
+		sq.Final = Rect{}
+		return true
+	})
+
+	// Other givens may be able to cover its squares now.
+	se.invalidate(r)
+}
+
+// forget removes the cached candidates which overlap r, from every given but
+// r's, once something has been put there.
+func (se *Session) forget(r Rect) {
+	for pos, cands := range se.cands {
+		if pos == r.Given {
+			continue
+		}
+		kept := cands[:0:0]
+		for _, cand := range cands {
+			if !cand.overlaps(r) {
+				kept = append(kept, cand)
+			}
+		}
+		se.cands[pos] = kept
+	}
+}
+
+// invalidate removes the cached candidates of every given with a Rect which
+// could overlap r, once something there has been taken away, so they're
+// enumerated again.
+func (se *Session) invalidate(r Rect) {
+	for pos := range se.cands {
+		// How far pos is from r in each direction, so any Rect enclosing pos
+		// which reaches r is at least one more than that on each side.
+		dist := max2(max2(r.A.Sub(pos), pos.Sub(r.B.Sub(Vec2{1, 1}))), ORIGIN)
+		if (dist[0]+1)*(dist[1]+1) <= se.board.Get(pos).Area {
+			delete(se.cands, pos)
+		}
+	}
+}
+
+// solve solves the board again, returning what changed.
+func (se *Session) solve() Diff {
+	old := se.solution
+	se.solution, se.failed, se.err, se.stats = nil, nil, nil, SolveStats{}
+
+	if err := se.board.checkArea(); err != nil {
+		se.err = err
+		return diffSolutions(old, nil)
+	}
+
+	deduced := []Event{}
+	s := newSolver(context.Background(), SolveOptions{
+		Observer: ObserverFunc(func(ev Event) {
+			switch ev.Kind {
+			case EventGivenForced, EventBlankForced, EventOverlap, EventProbe:
+				if ev.Depth == 0 {
+					deduced = append(deduced, ev)
+				}
+			}
+		}),
+	})
+	st := newStateCached(se.board, se.cands)
+	s.candidates = int64(len(st.cands))
+	s.prefer = st.idsOf(old)
+
+	err := s.node()
+	if err == nil {
+		err = s.redo(st, se.deduced)
+	}
+	if err == nil {
+		err = newWalk(s, st, 0).run()
+	}
+	se.stats = s.stats()
+	se.deduced = deduced
+	if err != nil {
+		se.failed = err
+		return diffSolutions(old, nil)
+	}
+	se.solution = st.rects()
+	return diffSolutions(old, se.solution)
+}
+
+// redo makes each of the deductions in steps again on st, which hasn't been
+// looked at yet, wherever it still follows from what's on st now, and emits
+// those it makes. Then only the givens and cells left with one candidate or
+// none are queued to be looked at, as nothing else can be deduced from one
+// on its own.
+func (s *solver) redo(st *state, steps []Event) error {
+	index := make(map[Vec2]int, len(st.givens))
+	for g, pos := range st.givens {
+		index[pos] = g
+	}
+	st.dirtyGivens.reset()
+	st.dirtyCells.reset()
+
+	var p *solver
+	for _, ev := range steps {
+		g, isGiven := index[ev.Pos]
+		switch {
+		case ev.Kind == EventGivenForced && isGiven:
+			if st.placed[g] < 0 && st.givenLive[g] == 1 {
+				id := st.live.nextIn(st.first[g], st.first[g+1])
+				s.emit(Event{Kind: ev.Kind, Pos: ev.Pos, Rect: st.cands[id]})
+				atomic.AddInt64(&s.forced, 1)
+				st.place(id)
+			}
+		case ev.Kind == EventBlankForced && ev.Pos.In(ORIGIN, Vec2{st.w, st.h}):
+			if c := st.cell(ev.Pos); !st.isFinal(c) && st.cellLive[c] == 1 {
+				id := st.firstLiveIn(c)
+				s.emit(Event{Kind: ev.Kind, Pos: ev.Pos, Rect: st.cands[id]})
+				atomic.AddInt64(&s.forced, 1)
+				st.place(id)
+			}
+		case ev.Kind == EventOverlap && isGiven:
+			if st.placed[g] < 0 && st.givenLive[g] >= 2 {
+				if r, ruledOut := st.overlap(g); ruledOut > 0 {
+					s.emit(Event{Kind: ev.Kind, Pos: ev.Pos, Rect: r})
+				}
+			}
+		case ev.Kind == EventProbe && isGiven:
+			// Whether it still fails depends on the whole board, so it's
+			// probed again, but nothing else is.
+			for id := st.first[g]; id < st.first[g+1]; id++ {
+				if st.cands[id] != ev.Rect || !st.live.has(id) {
+					continue
+				}
+				if p == nil {
+					p = s.prober()
+				}
+				failed, err := s.probe(p, st, id)
+				if err != nil {
+					return err
+				} else if failed {
+					s.emit(Event{Kind: ev.Kind, Pos: ev.Pos, Rect: ev.Rect})
+					atomic.AddInt64(&s.probed, 1)
+					st.kill(id)
+				}
+			}
+		}
+	}
+
+	for g := range st.givens {
+		if st.placed[g] < 0 && st.givenLive[g] <= 1 {
+			st.dirtyGivens.set(g)
+		}
+	}
+	for c := range st.cellCands {
+		if !st.isFinal(c) && st.cellLive[c] <= 1 {
+			st.dirtyCells.set(c)
+		}
+	}
+	return nil
+}
+
+// idsOf returns the IDs of the candidates which are among rects.
+func (st *state) idsOf(rects []Rect) bitset {
+	want := map[Rect]bool{}
+	for _, r := range rects {
+		want[r] = true
+	}
+
+	ids := newBitset(len(st.cands))
+	for id, r := range st.cands {
+		if want[r] {
+			ids.set(id)
+		}
+	}
+	return ids
+}
+
+// diffSolutions returns the Diff from one solution to another.
+func diffSolutions(before, after []Rect) Diff {
+	inBefore, inAfter := map[Rect]bool{}, map[Rect]bool{}
+	for _, r := range before {
+		inBefore[r] = true
+	}
+	for _, r := range after {
+		inAfter[r] = true
+	}
+
+	diff := Diff{Added: []Rect{}, Removed: []Rect{}}
+	for _, r := range after {
+		if !inBefore[r] {
+			diff.Added = append(diff.Added, r)
+		}
+	}
+	for _, r := range before {
+		if !inAfter[r] {
+			diff.Removed = append(diff.Removed, r)
+		}
+	}
+	return diff
+}
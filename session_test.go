@@ -0,0 +1,223 @@
+package shikaku
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// checkSession checks that se's solution is what diff says it changed to from
+// before, and that it's solvable exactly when a fresh solve says so.
+func checkSession(t *testing.T, se *Session, before []Rect, diff Diff) {
+	t.Helper()
+
+	want := map[Rect]bool{}
+	for _, r := range before {
+		want[r] = true
+	}
+	for _, r := range diff.Removed {
+		delete(want, r)
+	}
+	for _, r := range diff.Added {
+		want[r] = true
+	}
+	got := map[Rect]bool{}
+	for _, r := range se.Solution() {
+		got[r] = true
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff %+v from %v doesn't give %v", diff, before, se.Solution())
+	}
+
+	fresh := se.Board()
+	_, err := fresh.SolveContext(context.Background(), SolveOptions{})
+	if (err == nil) != (se.Err() == nil) {
+		t.Errorf("Session has error %v, but a fresh solve has %v", se.Err(), err)
+	}
+	if se.Err() == nil {
+		if violations := CheckSolution(se.Board(), se.Solution()); len(violations) > 0 {
+			t.Error("Solution is wrong:", violations)
+		}
+	} else if se.Solution() != nil {
+		t.Error("Has a solution despite error", se.Err())
+	}
+}
+
+func TestSession(t *testing.T) {
+	for _, boString := range append([]string{testGuessBoard}, testBoards...) {
+		bo, _ := NewBoardFromString(boString)
+		se := NewSession(bo)
+		checkSession(t, se, nil, diffSolutions(nil, se.Solution()))
+
+		if _, err := bo.SolveContext(context.Background(), SolveOptions{}); err != nil {
+			t.Fatal("Couldn't find solution to solvable puzzle:", err)
+		}
+		if !reflect.DeepEqual(se.Solution(), bo.Rects()) {
+			t.Errorf("Session found %v, expected %v", se.Solution(), bo.Rects())
+		}
+	}
+}
+
+func TestSessionEdit(t *testing.T) {
+	bo, _ := NewBoardFromString(testBoards[1])
+	se := NewSession(bo)
+	original := se.Solution()
+
+	edits := []struct {
+		name   string
+		edit   func() (Diff, error)
+		solved bool
+	}{
+		{"clear", func() (Diff, error) { return se.ClearGiven(Vec2{2, 2}) }, false},
+		{"set back", func() (Diff, error) { return se.SetGiven(Vec2{2, 2}, 3) }, true},
+		{"grow", func() (Diff, error) { return se.SetGiven(Vec2{4, 2}, 7) }, false},
+		{"shrink", func() (Diff, error) { return se.SetGiven(Vec2{4, 2}, 6) }, true},
+		{"move", func() (Diff, error) { return se.ClearGiven(Vec2{3, 0}) }, false},
+		{"move back", func() (Diff, error) { return se.SetGiven(Vec2{4, 0}, 2) }, true},
+	}
+	for _, tc := range edits {
+		before := se.Solution()
+		diff, err := tc.edit()
+		if err != nil {
+			t.Fatalf("Couldn't %s: %v", tc.name, err)
+		}
+		checkSession(t, se, before, diff)
+		if solved := se.Err() == nil; solved != tc.solved {
+			t.Errorf("After %s, solved is %v, expected %v: %v", tc.name, solved, tc.solved, se.Err())
+		}
+	}
+
+	// Putting everything back gives the original solution.
+	se.ClearGiven(Vec2{4, 0})
+	se.SetGiven(Vec2{3, 0}, 2)
+	if !reflect.DeepEqual(se.Solution(), original) {
+		t.Errorf("Found %v after undoing every edit, expected %v", se.Solution(), original)
+	}
+}
+
+func TestSessionLock(t *testing.T) {
+	bo, _ := NewBoardFromString(`
+		02 --
+		-- 02`)
+	se := NewSession(bo)
+
+	// Whichever solution was found first, locking one of the other's Rects
+	// gives that one.
+	left := Rect{Vec2{0, 0}, Vec2{1, 2}, Vec2{0, 0}}
+	right := Rect{Vec2{1, 0}, Vec2{2, 2}, Vec2{1, 1}}
+	before := se.Solution()
+	diff, err := se.Lock(left)
+	if err != nil {
+		t.Fatal("Couldn't lock:", err)
+	}
+	checkSession(t, se, before, diff)
+	if want := []Rect{left, right}; !reflect.DeepEqual(se.Solution(), want) {
+		t.Errorf("Found %v, expected %v", se.Solution(), want)
+	}
+
+	// The other Rect in the solution can be locked without solving again.
+	stats := se.Stats()
+	if diff, err := se.Lock(right); err != nil || len(diff.Added)+len(diff.Removed) > 0 {
+		t.Errorf("Locking %v gave %+v, %v", right, diff, err)
+	}
+	if se.Stats() != stats {
+		t.Error("Solved again after locking a Rect already in the solution")
+	}
+
+	// Nothing can be put in a locked Rect.
+	if _, err := se.Lock(Rect{Vec2{0, 1}, Vec2{2, 2}, Vec2{1, 1}}); err == nil {
+		t.Error("Locked a Rect overlapping another locked Rect")
+	}
+	if _, err := se.SetGiven(Vec2{0, 1}, 1); err == nil {
+		t.Error("Set a given in a locked Rect")
+	}
+
+	// Clearing a locked given unlocks it.
+	se.ClearGiven(Vec2{0, 0})
+	se.SetGiven(Vec2{0, 1}, 2)
+	if want := []Rect{left, right}; se.Err() != nil || reflect.DeepEqual(se.Solution(), want) {
+		t.Errorf("Found %v, %v after clearing the locked given", se.Solution(), se.Err())
+	}
+}
+
+func TestSessionInvalidEdits(t *testing.T) {
+	bo, _ := NewBoardFromString(testBoards[0])
+	se := NewSession(bo)
+	want := se.Board()
+
+	edits := []struct {
+		name string
+		edit func() (Diff, error)
+	}{
+		{"set off the board", func() (Diff, error) { return se.SetGiven(Vec2{5, 0}, 1) }},
+		{"set an empty given", func() (Diff, error) { return se.SetGiven(Vec2{0, 0}, 0) }},
+		{"clear a blank", func() (Diff, error) { return se.ClearGiven(Vec2{0, 0}) }},
+		{"clear off the board", func() (Diff, error) { return se.ClearGiven(Vec2{-1, 0}) }},
+		{"lock off the board", func() (Diff, error) { return se.Lock(Rect{Vec2{4, 0}, Vec2{6, 1}, Vec2{4, 0}}) }},
+		{"lock without a given", func() (Diff, error) { return se.Lock(Rect{Vec2{0, 0}, Vec2{1, 1}, Vec2{0, 0}}) }},
+		{"lock with two givens", func() (Diff, error) { return se.Lock(Rect{Vec2{0, 2}, Vec2{2, 3}, Vec2{0, 2}}) }},
+		{"lock the wrong area", func() (Diff, error) { return se.Lock(Rect{Vec2{0, 2}, Vec2{1, 4}, Vec2{0, 2}}) }},
+	}
+	for _, tc := range edits {
+		if _, err := tc.edit(); err == nil {
+			t.Errorf("Expected an error trying to %s", tc.name)
+		}
+	}
+	if !reflect.DeepEqual(se.Board(), want) {
+		t.Error("Invalid edits changed the board")
+	}
+}
+
+func TestSessionReuse(t *testing.T) {
+	bo, _ := NewBoardFromString(testGuessBoard)
+	se := NewSession(bo)
+	fresh := se.Stats()
+
+	// Only the givens near the edit need their candidates found again.
+	se.ClearGiven(Vec2{8, 8})
+	se.SetGiven(Vec2{8, 8}, 1)
+	if se.Err() != nil {
+		t.Fatal("Couldn't solve after putting the given back:", se.Err())
+	}
+	if stats := se.Stats(); stats.Checks >= fresh.Checks {
+		t.Errorf("Solving again took %d checks, and a fresh solve %d", stats.Checks, fresh.Checks)
+	}
+	if stats := se.Stats(); stats.Nodes > fresh.Nodes {
+		t.Errorf("Solving again took %d nodes, and a fresh solve %d", stats.Nodes, fresh.Nodes)
+	}
+
+	// The deductions of the last solve which still follow are made again
+	// first, so there's less left to propagate.
+	if stats := se.Stats(); stats.Passes >= fresh.Passes {
+		t.Errorf("Solving again took %d passes, and a fresh solve %d", stats.Passes, fresh.Passes)
+	}
+	if stats := se.Stats(); stats.Forced != fresh.Forced {
+		t.Errorf("Solving again forced %d Rects, and a fresh solve %d", stats.Forced, fresh.Forced)
+	}
+}
+
+func TestSessionUnsolvable(t *testing.T) {
+	bo, _ := NewBoardFromString(`
+		-- -- -- -- -- 01
+		04 -- -- 06 -- 03
+		-- -- 04 01 03 02
+		-- -- -- -- 02 --
+		01 02 -- -- -- 01
+		-- -- 03 03 -- --`)
+	se := NewSession(bo)
+
+	// Why it can't be solved is only worked out once it's asked for.
+	if se.err != nil {
+		t.Error("Explained the board before being asked:", se.err)
+	}
+	err := se.Err()
+	if _, ok := err.(*UnsolvableError); !ok {
+		t.Fatal("Expected an UnsolvableError, got", err)
+	}
+	if se.Err() != err {
+		t.Error("Explained the board again")
+	}
+	if se.Solution() != nil {
+		t.Error("Has a solution despite error", err)
+	}
+}
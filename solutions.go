@@ -0,0 +1,44 @@
+package shikaku
+
+import "context"
+
+// SolutionVisitor is a function called with each solution of a board, as the
+// Rects enclosing each given, returning true to advance or false to stop.
+type SolutionVisitor func(sol []Rect) (advance bool)
+
+// IterSolutions calls visitor with each solution of the board in turn, only
+// searching for the next one once visitor returns true. It returns nil once
+// every solution has been visited or visitor stops, and ErrCanceled if ctx is
+// done first. The board itself isn't modified.
+func (bo *Board) IterSolutions(ctx context.Context, visitor SolutionVisitor) error {
+	if err := bo.checkArea(); err != nil {
+		return err
+	}
+
+	s := newSolver(ctx, SolveOptions{})
+	s.visit = visitor
+
+	err := newWalk(s, newState(bo), 0).run()
+	if isAbort(err) && err != errStop {
+		return err
+	}
+	return nil
+}
+
+// CountSolutions counts the distinct ways the board can be solved, stopping
+// once it has found limit of them. If limit <= 0, every solution is counted.
+// The board itself isn't modified.
+func (bo *Board) CountSolutions(limit int) (int, error) {
+	count := 0
+	err := bo.IterSolutions(context.Background(), func(sol []Rect) bool {
+		count++
+		return limit <= 0 || count < limit
+	})
+	return count, err
+}
+
+// IsUnique returns true if the board has exactly one solution.
+func (bo *Board) IsUnique() (bool, error) {
+	count, err := bo.CountSolutions(2)
+	return count == 1, err
+}
@@ -0,0 +1,117 @@
+package shikaku
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestCountSolutions(t *testing.T) {
+	// testBoards[3] can be solved two ways, all the rest only one.
+	for i, boString := range testBoards {
+		t.Run("Board", func(t *testing.T) {
+			bo, _ := NewBoardFromString(boString)
+			origStr := bo.DebugString()
+
+			expected := 1
+			if i == 3 {
+				expected = 2
+			}
+
+			count, err := bo.CountSolutions(0)
+			if err != nil {
+				t.Error("Couldn't count solutions:", err)
+			}
+			if count != expected {
+				t.Errorf("Found %d solutions, expected %d", count, expected)
+			}
+
+			unique, err := bo.IsUnique()
+			if err != nil {
+				t.Error("Couldn't check uniqueness:", err)
+			}
+			if unique != (expected == 1) {
+				t.Errorf("IsUnique() = %v with %d solutions", unique, expected)
+			}
+
+			if bo.DebugString() != origStr {
+				t.Error("Counting solutions modified the board")
+			}
+		})
+	}
+}
+
+func TestCountSolutionsLimit(t *testing.T) {
+	bo, _ := NewBoardFromString(testBoards[3])
+
+	count, err := bo.CountSolutions(1)
+	if err != nil || count != 1 {
+		t.Errorf("CountSolutions(1) = %d, %v; expected 1, nil", count, err)
+	}
+}
+
+func TestCountSolutionsBad(t *testing.T) {
+	for _, boString := range testBadBoards {
+		bo, _ := NewBoardFromString(boString)
+
+		count, err := bo.CountSolutions(0)
+		if err == nil || count != 0 {
+			t.Errorf("CountSolutions() = %d, %v on a bad board; expected an error", count, err)
+		}
+	}
+
+	// Every blank of this one can be covered while one of its givens still
+	// can't be enclosed, so it has no solutions.
+	bo, _ := NewBoardFromString(`
+		-- -- -- -- -- 01
+		04 -- -- 06 -- 03
+		-- -- 04 01 03 02
+		-- -- -- -- 02 --
+		01 02 -- -- -- 01
+		-- -- 03 03 -- --`)
+	count, err := bo.CountSolutions(0)
+	if err != nil || count != 0 {
+		t.Errorf("CountSolutions() = %d, %v on an unsolvable board; expected 0, nil", count, err)
+	}
+	if unique, _ := bo.IsUnique(); unique {
+		t.Error("IsUnique() = true on an unsolvable board")
+	}
+}
+
+func TestIterSolutions(t *testing.T) {
+	bo, _ := NewBoardFromString(testBoards[3])
+	origStr := bo.DebugString()
+
+	// Both solutions, each enclosing every given, and different from each other.
+	sols := [][]Rect{}
+	err := bo.IterSolutions(context.Background(), func(sol []Rect) bool {
+		sols = append(sols, sol)
+		return true
+	})
+	if err != nil {
+		t.Fatal("Couldn't iterate solutions:", err)
+	}
+	if len(sols) != 2 {
+		t.Fatalf("Found %d solutions, expected 2", len(sols))
+	}
+	if len(sols[0]) != 14 || len(sols[1]) != 14 {
+		t.Errorf("Solutions don't enclose all 14 givens: %v", sols)
+	}
+	if fmt.Sprint(sols[0]) == fmt.Sprint(sols[1]) {
+		t.Errorf("Found the same solution twice: %v", sols[0])
+	}
+
+	// Stopping early visits only one.
+	visited := 0
+	err = bo.IterSolutions(context.Background(), func(sol []Rect) bool {
+		visited++
+		return false
+	})
+	if err != nil || visited != 1 {
+		t.Errorf("Stopping early visited %d solutions and returned %v", visited, err)
+	}
+
+	if bo.DebugString() != origStr {
+		t.Error("Iterating solutions modified the board")
+	}
+}
@@ -0,0 +1,443 @@
+package shikaku
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCanceled is returned when a solve is stopped by its context, either
+// because it was canceled or because its deadline passed.
+var ErrCanceled = errors.New("solve canceled")
+
+// ErrBudgetExceeded is returned when a solve explores more than
+// SolveOptions.MaxNodes search nodes without finishing.
+var ErrBudgetExceeded = errors.New("solve exceeded its node budget")
+
+// SolveOptions controls how a board is solved.
+type SolveOptions struct {
+	// Solver is the name of the registered Solver to use. If it's empty, the
+	// DefaultSolver is used.
+	Solver string
+
+	// MaxNodes is the most search nodes (the starting board, plus each
+	// speculative branch) to explore before giving up. Zero means no limit.
+	MaxNodes int
+
+	// Observer, if set, is told about each step the solver takes. When
+	// searching in parallel, it's still only called by one goroutine at a time.
+	Observer SolveObserver
+
+	// Branching is how the default solver chooses where to guess.
+	Branching Branching
+
+	// Workers is the most goroutines to search with at once. If it's more
+	// than 1, the default solver tries its guesses in parallel, each on its
+	// own copy of the board, and may find a different solution than it would
+	// otherwise on boards with more than one.
+	Workers int
+
+	// Seed chooses the order BranchRandom tries candidates in. Solving with
+	// the same Seed makes the same guesses, unless it's in parallel. If it's
+	// zero, a seed is chosen at random, and reported in SolveStats.Seed.
+	Seed int64
+
+	// RestartNodes, if more than zero, makes BranchRandom give up on its
+	// guesses so far and start again with a new seed, once it has explored
+	// RestartNodes times the next number in the Luby sequence (1, 1, 2, 1, 1,
+	// 2, 4, ...) of search nodes since it last started. Parallel solves
+	// don't restart.
+	RestartNodes int
+
+	// Progress, if set, is called by the default solver with how far it's
+	// got, at most once every ProgressInterval while it's searching, and once
+	// more when it finds a solution. Like Observer, it's only called by one
+	// goroutine at a time.
+	Progress func(Progress)
+
+	// ProgressInterval is the least time between calls to Progress. If it's
+	// zero, DefaultProgressInterval is used.
+	ProgressInterval time.Duration
+
+	// NoProbing stops the default solver trying each candidate Rect out
+	// before it guesses, to rule out those which lead straight to a
+	// contradiction.
+	NoProbing bool
+}
+
+// errStop is returned when a solve is stopped because it's found all the
+// solutions it was asked for.
+var errStop = errors.New("solve stopped")
+
+// errNext is returned in place of a solution when the search should carry on
+// looking for more of them.
+var errNext = errors.New("searching for the next solution")
+
+// solver searches for the solution to a board. Branches searched in parallel
+// each have their own solver, with their own context, sharing the rest.
+type solver struct {
+	ctx context.Context
+	*shared
+
+	// region holds the cells the solver is solving, and is nil for the
+	// whole board. It's done once they're all final, whatever's left
+	// elsewhere.
+	region bitset
+}
+
+// shared holds the state shared by every step of a single solve.
+type shared struct {
+	// The counters behind SolveStats, accessed atomically. They come first
+	// so they're aligned for 64-bit atomic access on every platform.
+	nodes       int64
+	maxDepth    int64
+	backtracks  int64
+	passes      int64
+	candidates  int64
+	forced      int64
+	guessed     int64
+	checks      int64
+	regions     int64
+	tableHits   int64
+	tableMisses int64
+	restarts    int64
+	probed      int64
+
+	// seed is the seed BranchRandom is using now, and startSeed the one it
+	// started with.
+	seed      int64
+	startSeed int64
+
+	// nextProgress is how long after start Progress may next be called.
+	nextProgress int64
+
+	opts  SolveOptions
+	start time.Time
+
+	// runStart is when the search was last carried on with, and ran how long
+	// it had run for before then, so time spent paused isn't counted.
+	runStart time.Time
+	ran      time.Duration
+
+	// visit, if set, is called with each solution, and returns true to keep
+	// searching for more of them. If it's nil, the search stops at the first
+	// solution and leaves it in the state.
+	visit func(sol []Rect) (advance bool)
+
+	// prefer, if set, holds the candidates to try before any others when
+	// guessing, like those in an earlier solution.
+	prefer bitset
+
+	// workers holds a token for each extra goroutine searching in parallel,
+	// or is nil if the search isn't parallel.
+	workers chan struct{}
+
+	// table holds the states which have been shown to have no solution.
+	// It's created by remember, which sets tableReady once it's safe to use.
+	table      table
+	tableOnce  sync.Once
+	tableReady int32
+
+	// observerMu makes sure only one goroutine calls the observer, or
+	// Progress, at once.
+	observerMu sync.Mutex
+}
+
+// newSolver creates a solver for a new search.
+func newSolver(ctx context.Context, opts SolveOptions) *solver {
+	now := time.Now()
+	s := &solver{ctx: ctx, shared: &shared{opts: opts, start: now, runStart: now}}
+	if opts.Workers > 1 {
+		s.workers = make(chan struct{}, opts.Workers-1)
+	}
+	if opts.Branching == BranchRandom {
+		s.seed = opts.Seed
+		for s.seed == 0 {
+			s.seed = rand.Int63()
+		}
+		s.startSeed = s.seed
+	}
+	s.nextProgress = int64(s.progressInterval())
+	return s
+}
+
+// stats returns the SolveStats for the search so far.
+func (s *solver) stats() SolveStats {
+	return SolveStats{
+		Nodes:       int(atomic.LoadInt64(&s.nodes)),
+		MaxDepth:    int(atomic.LoadInt64(&s.maxDepth)),
+		Backtracks:  int(atomic.LoadInt64(&s.backtracks)),
+		Passes:      int(atomic.LoadInt64(&s.passes)),
+		Candidates:  int(atomic.LoadInt64(&s.candidates)),
+		Forced:      int(atomic.LoadInt64(&s.forced)),
+		Guessed:     int(atomic.LoadInt64(&s.guessed)),
+		Checks:      int(atomic.LoadInt64(&s.checks)),
+		Regions:     int(atomic.LoadInt64(&s.regions)),
+		TableHits:   int(atomic.LoadInt64(&s.tableHits)),
+		TableMisses: int(atomic.LoadInt64(&s.tableMisses)),
+		Seed:        s.startSeed,
+		Restarts:    int(atomic.LoadInt64(&s.restarts)),
+		Probed:      int(atomic.LoadInt64(&s.probed)),
+		Duration:    s.elapsed(),
+	}
+}
+
+// elapsed returns how long the search has been running, not counting any time
+// it spent paused.
+func (s *solver) elapsed() time.Duration {
+	return s.ran + time.Since(s.runStart)
+}
+
+// reached records that the search has got to depth.
+func (s *solver) reached(depth int) {
+	for {
+		max := atomic.LoadInt64(&s.maxDepth)
+		if int64(depth) <= max || atomic.CompareAndSwapInt64(&s.maxDepth, max, int64(depth)) {
+			return
+		}
+	}
+}
+
+// Solve solves the Shikaku puzzle, finalizing every square of the board.
+func (bo *Board) Solve() error {
+	_, err := bo.SolveContext(context.Background(), SolveOptions{})
+	return err
+}
+
+// SolveContext solves the Shikaku puzzle like Solve, using the Solver named by
+// opts.Solver, and returns the work it took. It stops early with ErrCanceled
+// once ctx is done, or with ErrBudgetExceeded once it has explored
+// opts.MaxNodes search nodes.
+func (bo *Board) SolveContext(ctx context.Context, opts SolveOptions) (SolveStats, error) {
+	engine, err := LookupSolver(opts.Solver)
+	if err != nil {
+		return SolveStats{}, err
+	}
+
+	rects, stats, err := engine.Solve(ctx, bo, opts)
+	if err != nil {
+		return stats, err
+	}
+
+	for _, r := range rects {
+		bo.Finalize(r)
+	}
+	return stats, nil
+}
+
+// checkArea makes sure all the givens, added together, exactly cover the board.
+func (bo *Board) checkArea() error {
+	totalCovered := 0
+	bo.Iter(func(pos Vec2, sq *Square) bool {
+		totalCovered += sq.Area
+		return true
+	})
+	if totalCovered > bo.Height()*bo.Width() {
+		return errors.New("Covered area is greater than board area")
+	}
+	if totalCovered < bo.Height()*bo.Width() {
+		return errors.New("Covered area is less than board area")
+	}
+	return nil
+}
+
+// node counts one more search node, and checks whether the solve should stop.
+func (s *solver) node() error {
+	nodes := atomic.AddInt64(&s.nodes, 1)
+	if s.opts.MaxNodes > 0 && nodes > int64(s.opts.MaxNodes) {
+		return ErrBudgetExceeded
+	}
+	return s.check()
+}
+
+// check returns ErrCanceled if the solve's context is done.
+func (s *solver) check() error {
+	select {
+	case <-s.ctx.Done():
+		return ErrCanceled
+	default:
+		return nil
+	}
+}
+
+// emit tells the observer, if there is one, about a step.
+func (s *solver) emit(ev Event) {
+	if s.opts.Observer != nil {
+		s.observerMu.Lock()
+		defer s.observerMu.Unlock()
+		s.opts.Observer.Observe(ev)
+	}
+}
+
+// isAbort returns true if err stops the whole solve, rather than meaning that
+// one branch of it has no solution.
+func isAbort(err error) bool {
+	return err == ErrCanceled || err == ErrBudgetExceeded || err == errStop
+}
+
+// collectChecks adds the candidate checks made on st to the search's total.
+func (s *solver) collectChecks(st *state) {
+	atomic.AddInt64(&s.checks, int64(st.checks))
+	st.checks = 0
+}
+
+// found is called when the board is solved. It returns nil to accept the
+// solution, or an error to make the search keep looking for others.
+func (s *solver) found(st *state) error {
+	if s.visit == nil {
+		return nil
+	}
+	if !s.visit(st.rects()) {
+		return errStop
+	}
+	return errNext
+}
+
+// propagate refines the board until it's solved, or nothing more can be
+// deduced without guessing. It returns true if it's solved, or if the
+// solver's region is.
+/*
+
+Every candidate is enumerated once, when st is made. Placing a Rect rules out
+the candidates it collides with, and marks their givens and cells dirty.
+
+Repeat:
+	For each dirty Given:
+		if it has 0 candidates left, abort with error.
+		if it has 1, place it.
+
+	For each dirty Blank:
+		if it has 0 candidates left, abort with error.
+
+	If nothing's left unsolved
+		Done
+
+	For each dirty Blank:
+		if it has 1 candidate left, place it.
+
+	If nothing was placed
+		rule out candidates by overlap, then by probing.
+	If still nothing changed
+		Stop, and guess
+
+*/
+func (s *solver) propagate(st *state, depth int) (solved bool, err error) {
+	defer s.collectChecks(st)
+	s.reached(depth)
+
+	for {
+		if err := s.check(); err != nil {
+			return false, err
+		}
+		atomic.AddInt64(&s.passes, 1)
+
+		// Finalize if only one solution for anything.
+		// So count the number of times something's finalized.
+		countFinalized := 0
+
+		// For each Given which has lost candidates since the last pass
+		for g := st.dirtyGivens.nextIn(0, len(st.givens)); g >= 0; g = st.dirtyGivens.nextIn(g+1, len(st.givens)) {
+			st.dirtyGivens.unset(g)
+			if st.placed[g] >= 0 {
+				continue
+			}
+
+			if s.opts.Observer != nil {
+				possible := []Rect{}
+				for id := st.first[g]; id < st.first[g+1]; id++ {
+					if st.live.has(id) {
+						possible = append(possible, st.cands[id])
+					}
+				}
+				s.emit(Event{Kind: EventCandidates, Pos: st.givens[g], Depth: depth, Candidates: possible})
+			}
+
+			switch st.givenLive[g] {
+			case 0:
+				return false, errors.New("Invalid board, some givens can't be enclosed")
+			case 1:
+				// Only one solution, so finalize it.
+				id := st.live.nextIn(st.first[g], st.first[g+1])
+				s.emit(Event{Kind: EventGivenForced, Pos: st.givens[g], Rect: st.cands[id], Depth: depth})
+				atomic.AddInt64(&s.forced, 1)
+				countFinalized += st.place(id)
+			}
+		}
+
+		// For each Blank which has lost candidates since the last pass
+		cells := len(st.cellCands)
+		for c := st.dirtyCells.nextIn(0, cells); c >= 0; c = st.dirtyCells.nextIn(c+1, cells) {
+			if !st.isFinal(c) && st.cellLive[c] == 0 {
+				return false, errors.New("Invalid board, some squares weren't covered")
+			}
+		}
+
+		if st.remaining == 0 {
+			// Done. Everything's fine.
+			return true, nil
+		} else if s.region != nil && !st.unsolvedIn(s.region) {
+			// This region's done, and the rest is solved separately.
+			return true, nil
+		}
+
+		// Finalize squares with 1 suggestion, add to the count.
+		for c := st.dirtyCells.nextIn(0, cells); c >= 0; c = st.dirtyCells.nextIn(c+1, cells) {
+			st.dirtyCells.unset(c)
+			if st.isFinal(c) {
+				continue
+			}
+
+			switch st.cellLive[c] {
+			case 0:
+				// Its last candidate was ruled out by something finalized
+				// this pass, so nothing can cover it.
+				return false, errors.New("Invalid board, some squares weren't covered")
+			case 1:
+				//Make final.
+				sol := st.firstLiveIn(c)
+				s.emit(Event{Kind: EventBlankForced, Pos: st.pos(c), Rect: st.cands[sol], Depth: depth})
+				atomic.AddInt64(&s.forced, 1)
+				countFinalized += st.place(sol)
+			}
+		}
+
+		if countFinalized == 0 {
+			// Squares which every possible Rect for a given covers are its,
+			// so no other given's Rect can cover them.
+			for g := range st.givens {
+				if st.placed[g] >= 0 || st.givenLive[g] < 2 {
+					continue
+				}
+				if r, ruledOut := st.overlap(g); ruledOut > 0 {
+					s.emit(Event{Kind: EventOverlap, Pos: st.givens[g], Rect: r, Depth: depth})
+					countFinalized += ruledOut
+				}
+			}
+		}
+
+		if countFinalized == 0 && !s.opts.NoProbing {
+			// Candidates which lead straight to a contradiction can't be
+			// right, whatever else is guessed.
+			failed, err := s.probeAll(st)
+			if err != nil {
+				return false, err
+			}
+			for _, id := range failed {
+				s.emit(Event{Kind: EventProbe, Pos: st.givens[st.candGiven[id]], Rect: st.cands[id], Depth: depth})
+				atomic.AddInt64(&s.probed, 1)
+				st.kill(id)
+				countFinalized++
+			}
+		}
+
+		if countFinalized == 0 {
+			// Can't deterministically solve.
+			return false, nil
+		}
+
+		// Try refining it again.
+	}
+}
@@ -0,0 +1,115 @@
+package shikaku
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSolveContextCanceled(t *testing.T) {
+	bo, _ := NewBoardFromString(testBoards[4])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := bo.SolveContext(ctx, SolveOptions{})
+	if err != ErrCanceled {
+		t.Errorf("Expected ErrCanceled from a canceled context, got %v", err)
+	}
+}
+
+func TestSolveContextBudget(t *testing.T) {
+	// This board needs to guess once, so it takes 2 nodes.
+	bo, _ := NewBoardFromString(testBoards[3])
+
+	_, err := bo.Clone().SolveContext(context.Background(), SolveOptions{MaxNodes: 1})
+	if err != ErrBudgetExceeded {
+		t.Errorf("Expected ErrBudgetExceeded with 1 node, got %v", err)
+	}
+
+	_, err = bo.Clone().SolveContext(context.Background(), SolveOptions{MaxNodes: 2})
+	if err != nil {
+		t.Errorf("Couldn't solve within 2 nodes: %v", err)
+	}
+}
+
+func TestSolveParallel(t *testing.T) {
+	boards := append([]string{testBranchBoard}, testBoards...)
+	for _, boString := range boards {
+		t.Run("Board", func(t *testing.T) {
+			bo, _ := NewBoardFromString(boString)
+			unique, _ := bo.IsUnique()
+			serial := bo.Clone()
+			serial.Solve()
+
+			_, err := bo.SolveContext(context.Background(), SolveOptions{Workers: 4})
+			if err != nil {
+				t.Fatal("Couldn't find solution to solvable puzzle:", err)
+			}
+
+			if unique && bo.String() != serial.String() {
+				t.Error("Parallel solution differs from the serial one")
+				t.Log("Expected:\n" + serial.String())
+				t.Log("Actual:\n" + bo.String())
+			}
+		})
+	}
+}
+
+func TestSolveChecks(t *testing.T) {
+	for _, boString := range testBoards {
+		bo, _ := NewBoardFromString(boString)
+
+		// Each placement is checked once when it's enumerated, then a node
+		// checks each candidate at most once for each cell it covers, and
+		// once more when its given is placed.
+		enumerated, covered := 0, 0
+		bo.IterWhere(IsGiven, func(pos Vec2, sq *Square) bool {
+			placements(pos, sq.Area, bo.Size(), func(r Rect) { enumerated++ })
+			for _, r := range bo.Candidates(pos) {
+				covered += r.Width()*r.Height() + 1
+			}
+			return true
+		})
+
+		_, stats, err := SolverFunc(solveBacktrack).Solve(context.Background(), bo, SolveOptions{})
+		if err != nil {
+			t.Fatal("Couldn't find solution to solvable puzzle:", err)
+		}
+
+		limit := enumerated + stats.Nodes*covered
+		if stats.Checks == 0 || stats.Checks > limit {
+			t.Errorf("Made %d candidate checks, expected between 1 and %d", stats.Checks, limit)
+		}
+		t.Logf("%dx%d board: %d checks, %d nodes", bo.Width(), bo.Height(), stats.Checks, stats.Nodes)
+	}
+}
+
+func TestSolveStats(t *testing.T) {
+	for _, boString := range testBoards {
+		bo, _ := NewBoardFromString(boString)
+		givens := 0
+		bo.IterWhere(IsGiven, func(pos Vec2, sq *Square) bool {
+			givens++
+			return true
+		})
+
+		stats, err := bo.SolveContext(context.Background(), SolveOptions{})
+		if err != nil {
+			t.Fatal("Couldn't find solution to solvable puzzle:", err)
+		}
+		t.Logf("%+v", stats)
+
+		if stats.Passes == 0 || stats.Candidates < givens || stats.Duration <= 0 {
+			t.Errorf("Stats are missing work: %+v", stats)
+		}
+
+		if stats.Nodes == 1 {
+			// Solved by logic alone, placing each given once.
+			if stats.Forced != givens || stats.Guessed != 0 || stats.MaxDepth != 0 {
+				t.Errorf("Expected %d forced Rects and no guesses, got %+v", givens, stats)
+			}
+		} else if stats.Guessed != stats.Nodes-1 || stats.MaxDepth == 0 {
+			t.Errorf("Expected a guess for every node but the first, got %+v", stats)
+		}
+	}
+}
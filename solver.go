@@ -0,0 +1,158 @@
+package shikaku
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultSolver is the name of the Solver used when SolveOptions doesn't
+// choose one.
+const DefaultSolver = "backtrack"
+
+// SolveStats describes the work done to solve a board.
+type SolveStats struct {
+	// Nodes is the number of search nodes explored.
+	Nodes int
+
+	// MaxDepth is the most guesses the search had made at once.
+	MaxDepth int
+
+	// Backtracks is the number of times every guess at a node failed, so the
+	// search had to back up.
+	Backtracks int
+
+	// Passes is the number of propagation passes made over the board, by
+	// solvers which propagate.
+	Passes int
+
+	// Candidates is the number of candidate Rects generated for the givens.
+	Candidates int
+
+	// Forced is the number of Rects placed because nothing else would do,
+	// and Guessed the number placed speculatively, whether or not the guess
+	// worked out.
+	Forced  int
+	Guessed int
+
+	// Checks is the number of times a candidate Rect was checked against
+	// the board, either to enumerate it or to rule it out.
+	Checks int
+
+	// Regions is the number of independent regions the board was split into
+	// and solved separately, by solvers which split it.
+	Regions int
+
+	// TableHits is the number of guesses which were already known not to
+	// work, from another order of guesses reaching the same Rects, and
+	// TableMisses the number which had to be searched.
+	TableHits   int
+	TableMisses int
+
+	// Seed is the seed BranchRandom started with, which can be passed as
+	// SolveOptions.Seed to make the same guesses again. It's zero for other
+	// Branchings.
+	Seed int64
+
+	// Restarts is the number of times the search gave up on its guesses
+	// and started again, with SolveOptions.RestartNodes.
+	Restarts int
+
+	// Probed is the number of candidate Rects ruled out by probing, because
+	// placing them led straight to a contradiction.
+	Probed int
+
+	// Duration is the wall-clock time the solve took.
+	Duration time.Duration
+}
+
+// Solver is an engine which finds solutions to boards.
+type Solver interface {
+	// Solve finds a solution to bo, returning the Rect enclosing each given in
+	// row-major order of their givens. bo itself isn't modified.
+	Solve(ctx context.Context, bo *Board, opts SolveOptions) ([]Rect, SolveStats, error)
+}
+
+// SolverFunc is an adapter to use an ordinary function as a Solver.
+type SolverFunc func(ctx context.Context, bo *Board, opts SolveOptions) ([]Rect, SolveStats, error)
+
+// Solve calls f(ctx, bo, opts).
+func (f SolverFunc) Solve(ctx context.Context, bo *Board, opts SolveOptions) ([]Rect, SolveStats, error) {
+	return f(ctx, bo, opts)
+}
+
+var (
+	solversMu sync.RWMutex
+	solvers   = map[string]Solver{}
+)
+
+func init() {
+	RegisterSolver(DefaultSolver, SolverFunc(solveBacktrack))
+}
+
+// RegisterSolver makes a Solver available by name to LookupSolver and
+// SolveOptions. Panics if s is nil, or if name is already registered.
+func RegisterSolver(name string, s Solver) {
+	solversMu.Lock()
+	defer solversMu.Unlock()
+
+	if s == nil {
+		panic("RegisterSolver() passed a nil Solver")
+	}
+	if _, dup := solvers[name]; dup {
+		panic("RegisterSolver() called twice for " + name)
+	}
+	solvers[name] = s
+}
+
+// LookupSolver returns the Solver registered as name, or the DefaultSolver if
+// name is empty.
+func LookupSolver(name string) (Solver, error) {
+	if name == "" {
+		name = DefaultSolver
+	}
+
+	solversMu.RLock()
+	defer solversMu.RUnlock()
+
+	s, ok := solvers[name]
+	if !ok {
+		return nil, fmt.Errorf("No solver named '%s'", name)
+	}
+	return s, nil
+}
+
+// Solvers returns the sorted names of all the registered Solvers.
+func Solvers() []string {
+	solversMu.RLock()
+	defer solversMu.RUnlock()
+
+	names := make([]string, 0, len(solvers))
+	for name := range solvers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// solveBacktrack is the DefaultSolver. It finalizes every square it can
+// deduce, then guesses and backtracks when it can't deduce any more.
+func solveBacktrack(ctx context.Context, bo *Board, opts SolveOptions) ([]Rect, SolveStats, error) {
+	if err := bo.checkArea(); err != nil {
+		return nil, SolveStats{}, err
+	}
+
+	se, err := bo.NewSearch(opts)
+	if err != nil {
+		return nil, SolveStats{}, err
+	}
+
+	rects, err := se.Run(ctx)
+	stats := se.Stats()
+	if err != nil {
+		return nil, stats, explain(ctx, bo, err)
+	}
+	return rects, stats, nil
+}
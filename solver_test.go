@@ -0,0 +1,95 @@
+package shikaku
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestSolvers(t *testing.T) {
+	for _, name := range Solvers() {
+		t.Run(name, func(t *testing.T) {
+			engine, err := LookupSolver(name)
+			if err != nil {
+				t.Fatal("Couldn't look up a registered solver:", err)
+			}
+
+			for _, boString := range testBoards {
+				bo, _ := NewBoardFromString(boString)
+				origStr := bo.DebugString()
+
+				rects, _, err := engine.Solve(context.Background(), bo, SolveOptions{})
+				if err != nil {
+					t.Error("Couldn't find solution to solvable puzzle:", err)
+					continue
+				}
+
+				if bo.DebugString() != origStr {
+					t.Error("Solver modified the board it was given")
+				}
+
+				// Puzzles with only one solution should all be solved the same way.
+				if unique, _ := bo.IsUnique(); unique {
+					expected, _, _ := SolverFunc(solveBacktrack).Solve(context.Background(), bo, SolveOptions{})
+					if fmt.Sprint(rects) != fmt.Sprint(expected) {
+						t.Errorf("Solution differs from the default solver's")
+						t.Log("Expected:", expected)
+						t.Log("Actual:", rects)
+					}
+				}
+
+				for _, r := range rects {
+					bo.Finalize(r)
+				}
+				bo.Iter(func(pos Vec2, sq *Square) bool {
+					if IsNotFinal(*sq) || IsUnsolvedGiven(*sq) {
+						t.Errorf("Square %v isn't covered by the solution", pos)
+						return false
+					}
+					return true
+				})
+			}
+		})
+	}
+}
+
+func TestLookupSolver(t *testing.T) {
+	if _, err := LookupSolver(""); err != nil {
+		t.Error("Couldn't look up the default solver:", err)
+	}
+
+	if _, err := LookupSolver("nope"); err == nil {
+		t.Error("Didn't blow up looking up a solver that doesn't exist")
+	}
+}
+
+func TestRegisterSolverTwice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Didn't panic registering a solver name twice")
+		}
+	}()
+	RegisterSolver(DefaultSolver, SolverFunc(solveBacktrack))
+}
+
+func BenchmarkSolvers(b *testing.B) {
+	for _, name := range Solvers() {
+		engine, _ := LookupSolver(name)
+		b.Run(name, func(b *testing.B) {
+			for _, boString := range testBoards {
+				bo, _ := NewBoardFromString(boString)
+				b.Run("Board", func(b *testing.B) {
+					nodes := 0
+					for i := 0; i < b.N; i++ {
+						_, stats, err := engine.Solve(context.Background(), bo, SolveOptions{})
+						if err != nil {
+							b.Fatalf("Solve failed, see TestSolvers for details")
+						}
+						nodes += stats.Nodes
+					}
+					b.ReportMetric(float64(nodes)/float64(b.N), "nodes/op")
+				})
+			}
+		})
+	}
+}
@@ -15,6 +15,12 @@ type Square struct {
 
 	// For a blank square, possible values for its parent Rects
 	Possible []Rect
+
+	// Owner is the given whose Rect must enclose a blank square, if Owned.
+	// It can be known before the Rect itself is, when every possible Rect
+	// for the given covers the square.
+	Owner Vec2
+	Owned bool
 }
 
 // NewBlank creates a blank Square
@@ -44,6 +50,10 @@ func (sq Square) String() string {
 		str += fmt.Sprintf("Final(%v) ", sq.Final)
 	}
 
+	if IsOwned(sq) {
+		str += fmt.Sprintf("Owned(%v) ", sq.Owner)
+	}
+
 	if len(sq.Possible) > 0 {
 		str += fmt.Sprintf("Blank%v ", sq.Possible)
 	}
@@ -86,6 +96,12 @@ func IsAny(sq Square) bool {
 	return true
 }
 
+// IsOwned returns true if a square isn't final, but it's known which given
+// will enclose it.
+func IsOwned(sq Square) bool {
+	return sq.Owned && !IsFinal(sq)
+}
+
 // IsGiven returns if a square is Given
 func IsGiven(sq Square) bool {
 	return sq.Area > 0
@@ -0,0 +1,478 @@
+package shikaku
+
+// layout is the part of a board which doesn't change while it's solved: its
+// givens, and every Rect each of them could possibly have.
+type layout struct {
+	w, h int
+
+	// givens holds the position of each given, in row-major order.
+	givens []Vec2
+
+	// cands holds each candidate Rect, by candidate ID. The candidates of
+	// given g are cands[first[g]:first[g+1]], in the order Board.Candidates
+	// returns them.
+	cands []Rect
+	first []int
+
+	// candGiven holds the index in givens of each candidate's given.
+	candGiven []int
+
+	// cellCands holds the IDs of the candidates covering each cell, in
+	// ascending order. Cells are indexed in row-major order.
+	cellCands [][]int32
+}
+
+// state is the solver's representation of a board. The board is turned into a
+// state before it's solved, and back into Rects only once there's a solution.
+//
+// Candidates are only enumerated once, when the state is created. After that,
+// they're ruled out as the Rects they collide with are placed, and the givens
+// and cells they cover are queued to be looked at again. Guesses are undone
+// with the trail, rather than by copying the whole state.
+type state struct {
+	*layout
+
+	// occupied holds a bitset of the final cells in each row. Givens are
+	// always final, like with IsFinal.
+	occupied []bitset
+
+	// placed holds the candidate ID each given is finalized to, or -1.
+	placed []int
+
+	// live holds the candidates which haven't been ruled out yet.
+	live bitset
+
+	// givenLive and cellLive count the live candidates of each given, and
+	// covering each cell.
+	givenLive []int32
+	cellLive  []int32
+
+	// dirtyGivens and dirtyCells hold the givens and cells which have lost
+	// candidates since the solver last looked at them.
+	dirtyGivens bitset
+	dirtyCells  bitset
+
+	// owner holds the index in givens of the given each cell is known to
+	// belong to, or -1, for cells which aren't final.
+	owner []int32
+
+	// remaining is the number of cells which aren't final yet.
+	remaining int
+
+	// hash is the Zobrist hash of the placed candidates.
+	hash uint64
+
+	// trail records every change made to the state, so they can be undone
+	// back to an earlier point in it.
+	trail []change
+
+	// checks counts the candidates checked against the board, since the
+	// solver last collected them.
+	checks int
+}
+
+// newState creates the state for a board, with the Candidates of each of its
+// givens, and everything final on it still final.
+func newState(bo *Board) *state {
+	return newStateCached(bo, nil)
+}
+
+// newStateCached is like newState, but takes the candidates of each unsolved
+// given from cache, by position, enumerating and adding them only if they
+// aren't there. Those in the cache must be the ones newState would find.
+func newStateCached(bo *Board, cache map[Vec2][]Rect) *state {
+	w, h := bo.Width(), bo.Height()
+	lay := &layout{w: w, h: h, cellCands: make([][]int32, w*h)}
+	st := &state{layout: lay, occupied: make([]bitset, h)}
+
+	// This is done for every solve, so the squares are read straight from
+	// the grid, rather than with closures through Board.Iter, and every row
+	// of occupied shares one backing array.
+	words := len(newBitset(w))
+	occupied := make(bitset, h*words)
+	finals := newFinalCounts(w, h)
+	owned := false
+	for y, row := range bo.Grid {
+		st.occupied[y] = occupied[y*words : (y+1)*words : (y+1)*words]
+		for x := range row {
+			sq := &row[x]
+			if IsGiven(*sq) {
+				lay.givens = append(lay.givens, Vec2{x, y})
+			}
+			final := IsFinal(*sq)
+			if final {
+				st.occupied[y].set(x)
+			} else {
+				st.remaining++
+			}
+			finals.add(x, y, final)
+			owned = owned || IsOwned(*sq)
+		}
+	}
+
+	lay.first = make([]int, len(lay.givens)+1)
+	st.placed = make([]int, len(lay.givens))
+	for g, pos := range lay.givens {
+		sq := bo.Get(pos)
+		if !IsUnsolvedGiven(*sq) {
+			st.placed[g] = len(lay.cands)
+			st.hash ^= zobrist(len(lay.cands))
+			lay.cands = append(lay.cands, sq.Final)
+		} else if cands, ok := cache[pos]; ok {
+			st.placed[g] = -1
+			lay.cands = append(lay.cands, cands...)
+		} else {
+			// The same Rects as bo.Candidates(pos). Its own given is the
+			// only final cell any of them can cover.
+			st.placed[g] = -1
+			start := len(lay.cands)
+			placements(pos, sq.Area, bo.Size(), func(r Rect) {
+				st.checks++
+				if finals.in(r) == 1 && !(owned && bo.Collides(r)) {
+					lay.cands = append(lay.cands, r)
+				}
+			})
+			if cache != nil {
+				cache[pos] = append([]Rect{}, lay.cands[start:]...)
+			}
+		}
+		lay.first[g+1] = len(lay.cands)
+	}
+	lay.candGiven = make([]int, len(lay.cands))
+	for g := range lay.givens {
+		for id := lay.first[g]; id < lay.first[g+1]; id++ {
+			lay.candGiven[id] = g
+		}
+	}
+
+	st.owner = make([]int32, w*h)
+	for c := range st.owner {
+		st.owner[c] = -1
+	}
+	if owned {
+		index := map[Vec2]int32{}
+		for g, pos := range lay.givens {
+			index[pos] = int32(g)
+		}
+		for y, row := range bo.Grid {
+			for x, sq := range row {
+				if g, ok := index[sq.Owner]; ok && IsOwned(sq) {
+					st.owner[lay.cell(Vec2{x, y})] = g
+				}
+			}
+		}
+	}
+
+	// Count the candidates covering each cell first, so they can all share
+	// one backing array.
+	counts := make([]int, lay.w*lay.h)
+	total := 0
+	for _, r := range lay.cands {
+		for y := r.A[1]; y < r.B[1]; y++ {
+			for c := lay.cell(Vec2{r.A[0], y}); c < lay.cell(Vec2{r.B[0], y}); c++ {
+				counts[c]++
+			}
+		}
+		total += r.Width() * r.Height()
+	}
+	ids := make([]int32, total)
+	for c, n := range counts {
+		lay.cellCands[c] = ids[:0:n]
+		ids = ids[n:]
+	}
+	for id, r := range lay.cands {
+		for y := r.A[1]; y < r.B[1]; y++ {
+			for c := lay.cell(Vec2{r.A[0], y}); c < lay.cell(Vec2{r.B[0], y}); c++ {
+				lay.cellCands[c] = append(lay.cellCands[c], int32(id))
+			}
+		}
+	}
+
+	// Nothing's been ruled out yet, so every given and cell needs looking at.
+	st.live = newBitset(len(lay.cands))
+	st.live.setIn(0, len(lay.cands))
+	st.givenLive = make([]int32, len(lay.givens))
+	for g := range lay.givens {
+		st.givenLive[g] = int32(lay.first[g+1] - lay.first[g])
+	}
+	st.cellLive = make([]int32, len(lay.cellCands))
+	for c, ids := range lay.cellCands {
+		st.cellLive[c] = int32(len(ids))
+	}
+	st.dirtyGivens = newBitset(len(lay.givens))
+	st.dirtyGivens.setIn(0, len(lay.givens))
+	st.dirtyCells = newBitset(len(lay.cellCands))
+	st.dirtyCells.setIn(0, len(lay.cellCands))
+
+	// Leave room for every change along one path through the search: each
+	// candidate ruled out, each given placed, and each cell made final and
+	// owned.
+	st.trail = make([]change, 0, len(lay.cands)+len(lay.givens)+2*w*h)
+
+	return st
+}
+
+// finalCounts is a summed-area table of the final cells on a board, so the
+// number of them any Rect covers can be found without looking at each one.
+type finalCounts struct {
+	w int
+
+	// sums holds how many final cells are above and to the left of each
+	// corner between cells, in row-major order, w+1 to a row.
+	sums []int32
+}
+
+// newFinalCounts creates an empty table for a board of w by h cells.
+func newFinalCounts(w, h int) *finalCounts {
+	return &finalCounts{w: w, sums: make([]int32, (w+1)*(h+1))}
+}
+
+// add adds the cell at x, y to the table, counting it if it's final. Cells
+// must be added in row-major order.
+func (fc *finalCounts) add(x, y int, final bool) {
+	stride := fc.w + 1
+	i := (y+1)*stride + x + 1
+	fc.sums[i] = fc.sums[i-1] + fc.sums[i-stride] - fc.sums[i-stride-1]
+	if final {
+		fc.sums[i]++
+	}
+}
+
+// in returns the number of final cells r covers.
+func (fc *finalCounts) in(r Rect) int {
+	stride := fc.w + 1
+	return int(fc.sums[r.B[1]*stride+r.B[0]] - fc.sums[r.A[1]*stride+r.B[0]] -
+		fc.sums[r.B[1]*stride+r.A[0]] + fc.sums[r.A[1]*stride+r.A[0]])
+}
+
+// cell returns the index of the cell at pos.
+func (lay *layout) cell(pos Vec2) int {
+	return pos[1]*lay.w + pos[0]
+}
+
+// pos returns the position of cell c.
+func (lay *layout) pos(c int) Vec2 {
+	return Vec2{c % lay.w, c / lay.w}
+}
+
+// clone returns a copy of the state, which can be modified without affecting
+// the original. The layout is shared.
+func (st *state) clone() *state {
+	clone := &state{
+		layout:      st.layout,
+		occupied:    make([]bitset, len(st.occupied)),
+		placed:      append([]int{}, st.placed...),
+		live:        append(bitset{}, st.live...),
+		givenLive:   append([]int32{}, st.givenLive...),
+		cellLive:    append([]int32{}, st.cellLive...),
+		owner:       append([]int32{}, st.owner...),
+		dirtyGivens: append(bitset{}, st.dirtyGivens...),
+		dirtyCells:  append(bitset{}, st.dirtyCells...),
+		remaining:   st.remaining,
+		hash:        st.hash,
+	}
+	for y, row := range st.occupied {
+		clone.occupied[y] = append(bitset{}, row...)
+	}
+	return clone
+}
+
+// adopt places every candidate placed in src, which must share st's layout,
+// for the givens in region, or every given if region is nil.
+func (st *state) adopt(src *state, region bitset) {
+	for g, pos := range st.givens {
+		if id := src.placed[g]; id >= 0 && st.placed[g] < 0 && (region == nil || region.has(st.cell(pos))) {
+			st.place(id)
+		}
+	}
+}
+
+// isFinal returns true if cell c is final.
+func (st *state) isFinal(c int) bool {
+	return st.occupied[c/st.w].has(c % st.w)
+}
+
+// place finalizes candidate id, and returns the number of cells which
+// weren't already final. Every other candidate of its given, and every
+// candidate of another given overlapping it, is ruled out.
+func (st *state) place(id int) (count int) {
+	g := st.candGiven[id]
+	st.trail = append(st.trail, change{changePlace, int32(id), int32(st.placed[g])})
+	st.placed[g] = id
+	st.hash ^= zobrist(id)
+	for other := st.first[g]; other < st.first[g+1]; other++ {
+		st.checks++
+		if other != id && st.live.has(other) {
+			st.kill(other)
+		}
+	}
+
+	r := st.cands[id]
+	for y := r.A[1]; y < r.B[1]; y++ {
+		for x := r.A[0]; x < r.B[0]; x++ {
+			if st.occupied[y].has(x) {
+				continue
+			}
+			st.occupied[y].set(x)
+			st.remaining--
+			st.trail = append(st.trail, change{changeOccupy, int32(st.cell(Vec2{x, y})), 0})
+			count++
+
+			for _, other := range st.cellCands[st.cell(Vec2{x, y})] {
+				st.checks++
+				if int(other) != id && st.live.has(int(other)) {
+					st.kill(int(other))
+				}
+			}
+		}
+	}
+	return count
+}
+
+// kill rules out candidate id, queueing its given and the cells it covers to
+// be looked at again.
+func (st *state) kill(id int) {
+	st.live.unset(id)
+	st.trail = append(st.trail, change{changeKill, int32(id), 0})
+
+	g := st.candGiven[id]
+	st.givenLive[g]--
+	st.dirtyGivens.set(g)
+
+	r := st.cands[id]
+	for y := r.A[1]; y < r.B[1]; y++ {
+		for x := r.A[0]; x < r.B[0]; x++ {
+			c := st.cell(Vec2{x, y})
+			st.cellLive[c]--
+			st.dirtyCells.set(c)
+		}
+	}
+}
+
+// overlap marks the cells which aren't final, and which every live
+// candidate of given g covers, as owned by it. It returns the Rect they make
+// up, and rules out every candidate of another given covering them,
+// returning how many were.
+func (st *state) overlap(g int) (r Rect, ruledOut int) {
+	r = Rect{ORIGIN, Vec2{st.w, st.h}, st.givens[g]}
+	for id := st.first[g]; id < st.first[g+1]; id++ {
+		if st.live.has(id) {
+			r.A, r.B = max2(r.A, st.cands[id].A), min2(r.B, st.cands[id].B)
+		}
+	}
+
+	for y := r.A[1]; y < r.B[1]; y++ {
+		for x := r.A[0]; x < r.B[0]; x++ {
+			c := st.cell(Vec2{x, y})
+			if st.isFinal(c) {
+				continue
+			}
+			if st.owner[c] != int32(g) {
+				st.trail = append(st.trail, change{changeOwner, int32(c), st.owner[c]})
+				st.owner[c] = int32(g)
+			}
+
+			for _, other := range st.cellCands[c] {
+				st.checks++
+				if st.candGiven[other] != g && st.live.has(int(other)) {
+					st.kill(int(other))
+					ruledOut++
+				}
+			}
+		}
+	}
+	return r, ruledOut
+}
+
+// changeKind is a kind of change to a state, recorded in its trail.
+type changeKind uint8
+
+const (
+	// changeKill is when a candidate is ruled out: value is its ID.
+	changeKill changeKind = iota
+
+	// changePlace is when a candidate is placed: value is its ID, and old
+	// is what its given was placed as before.
+	changePlace
+
+	// changeOccupy is when a cell is made final: value is the cell.
+	changeOccupy
+
+	// changeOwner is when a cell is found to belong to a given: value is the
+	// cell, and old is its owner before.
+	changeOwner
+)
+
+// change is a single change to a state, recorded in its trail.
+type change struct {
+	kind  changeKind
+	value int32
+	old   int32
+}
+
+// undo undoes every change made to st since its trail was mark long.
+//
+// Nothing is queued to be looked at again, so mark must have been taken when
+// nothing was, like when the solver has to guess.
+func (st *state) undo(mark int) {
+	for i := len(st.trail) - 1; i >= mark; i-- {
+		ch := st.trail[i]
+		switch ch.kind {
+		case changeKill:
+			id := int(ch.value)
+			st.live.set(id)
+			st.givenLive[st.candGiven[id]]++
+			r := st.cands[id]
+			for y := r.A[1]; y < r.B[1]; y++ {
+				for x := r.A[0]; x < r.B[0]; x++ {
+					st.cellLive[st.cell(Vec2{x, y})]++
+				}
+			}
+		case changePlace:
+			st.placed[st.candGiven[ch.value]] = int(ch.old)
+			st.hash ^= zobrist(int(ch.value))
+		case changeOccupy:
+			pos := st.pos(int(ch.value))
+			st.occupied[pos[1]].unset(pos[0])
+			st.remaining++
+		case changeOwner:
+			st.owner[ch.value] = ch.old
+		}
+	}
+	st.trail = st.trail[:mark]
+	st.dirtyGivens.reset()
+	st.dirtyCells.reset()
+}
+
+// liveIn returns the live candidates covering cell c, in ascending order.
+func (st *state) liveIn(c int) []int {
+	ids := []int{}
+	for _, id := range st.cellCands[c] {
+		if st.live.has(int(id)) {
+			ids = append(ids, int(id))
+		}
+	}
+	return ids
+}
+
+// firstLiveIn returns the first live candidate covering cell c, or -1.
+func (st *state) firstLiveIn(c int) int {
+	for _, id := range st.cellCands[c] {
+		if st.live.has(int(id)) {
+			return int(id)
+		}
+	}
+	return -1
+}
+
+// rects returns the final Rect of each solved given, in row-major order of
+// their givens, like Board.Rects.
+func (st *state) rects() []Rect {
+	rects := []Rect{}
+	for _, id := range st.placed {
+		if id >= 0 {
+			rects = append(rects, st.cands[id])
+		}
+	}
+	return rects
+}
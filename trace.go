@@ -0,0 +1,113 @@
+package shikaku
+
+import "fmt"
+
+// EventKind is the kind of step a solver took.
+type EventKind int
+
+const (
+	// EventCandidates is when the remaining candidates of a given are
+	// re-examined: at first, and again each time some are ruled out.
+	EventCandidates EventKind = iota
+
+	// EventGivenForced is when a given is finalized, because it only has one
+	// possible Rect.
+	EventGivenForced
+
+	// EventBlankForced is when a blank is finalized, because only one Rect
+	// could possibly cover it.
+	EventBlankForced
+
+	// EventBranch is when a Rect is guessed, because nothing more can be
+	// deduced.
+	EventBranch
+
+	// EventBranchFailed is when a guessed Rect turns out not to lead to a
+	// solution.
+	EventBranchFailed
+
+	// EventBacktrack is when every guess for a square has failed, so the
+	// guess which led to it must be wrong too.
+	EventBacktrack
+
+	// EventOverlap is when the squares covered by every possible Rect of a
+	// given are marked as owned by it, ruling out other givens' Rects there.
+	EventOverlap
+
+	// EventRestart is when every guess made so far is undone, to start
+	// guessing again in a new order.
+	EventRestart
+
+	// EventProbe is when a given's Rect is ruled out, because placing it
+	// leads straight to a contradiction.
+	EventProbe
+)
+
+var eventKindNames = []string{
+	EventCandidates:   "Candidates",
+	EventGivenForced:  "GivenForced",
+	EventBlankForced:  "BlankForced",
+	EventBranch:       "Branch",
+	EventBranchFailed: "BranchFailed",
+	EventBacktrack:    "Backtrack",
+	EventOverlap:      "Overlap",
+	EventRestart:      "Restart",
+	EventProbe:        "Probe",
+}
+
+// String returns the name of the EventKind.
+func (k EventKind) String() string {
+	if k < 0 || int(k) >= len(eventKindNames) {
+		return fmt.Sprintf("EventKind(%d)", int(k))
+	}
+	return eventKindNames[k]
+}
+
+// Event describes a single step taken by a solver.
+type Event struct {
+	Kind EventKind
+
+	// Pos is the square the step is about: the given whose candidates were
+	// re-examined, which was forced, which owned squares or whose Rect was
+	// ruled out by probing, the blank which was forced, or the square whose
+	// Possibles were guessed. It's empty for EventRestart.
+	Pos Vec2
+
+	// Rect is the Rect which was finalized, guessed or ruled out, or the
+	// squares which were owned for EventOverlap. It's empty for
+	// EventCandidates, EventBacktrack and EventRestart.
+	Rect Rect
+
+	// Depth is the number of guesses the solver had made when it took the step.
+	Depth int
+
+	// Candidates holds the given's remaining candidates, for EventCandidates.
+	Candidates []Rect
+}
+
+// String returns a string representation of the Event.
+func (ev Event) String() string {
+	switch ev.Kind {
+	case EventCandidates:
+		return fmt.Sprintf("%d %v %v: %v", ev.Depth, ev.Kind, ev.Pos, ev.Candidates)
+	case EventBacktrack:
+		return fmt.Sprintf("%d %v %v", ev.Depth, ev.Kind, ev.Pos)
+	case EventRestart:
+		return fmt.Sprintf("%d %v", ev.Depth, ev.Kind)
+	default:
+		return fmt.Sprintf("%d %v %v: %v", ev.Depth, ev.Kind, ev.Pos, ev.Rect)
+	}
+}
+
+// SolveObserver receives each Event as a solver takes the step it describes.
+type SolveObserver interface {
+	Observe(ev Event)
+}
+
+// ObserverFunc is an adapter to use an ordinary function as a SolveObserver.
+type ObserverFunc func(ev Event)
+
+// Observe calls f(ev).
+func (f ObserverFunc) Observe(ev Event) {
+	f(ev)
+}
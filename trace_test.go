@@ -0,0 +1,148 @@
+package shikaku
+
+import (
+	"context"
+	"testing"
+)
+
+// Without probing, the first guess for this board is wrong.
+const testBranchBoard = `
+	04 -- 02 --
+	-- -- 03 --
+	-- 03 -- --
+	-- -- -- 04`
+
+// testOverlapBoard can't be solved without knowing which squares the 6s
+// must cover, even before they're placed.
+const testOverlapBoard = `
+	-- -- 06 --
+	-- -- -- --
+	-- 06 -- --
+	01 01 01 01`
+
+func TestObserver(t *testing.T) {
+	bo, _ := NewBoardFromString(testBranchBoard)
+
+	events := []Event{}
+	counts := map[EventKind]int{}
+	_, err := bo.SolveContext(context.Background(), SolveOptions{
+		NoProbing: true,
+		Observer: ObserverFunc(func(ev Event) {
+			events = append(events, ev)
+			counts[ev.Kind]++
+		}),
+	})
+	if err != nil {
+		t.Fatal("Couldn't find solution to solvable puzzle:", err)
+	}
+
+	for _, kind := range []EventKind{EventCandidates, EventGivenForced, EventBranch, EventBranchFailed} {
+		if counts[kind] == 0 {
+			t.Errorf("No %v events", kind)
+		}
+	}
+
+	// Walk backwards, so forced events inside a failed branch are known to be
+	// there, and aren't compared against the solution.
+	failedDepth := -1
+	for i := len(events) - 1; i >= 0; i-- {
+		ev := events[i]
+		inFailed := failedDepth >= 0 && ev.Depth > failedDepth
+
+		switch ev.Kind {
+		case EventCandidates:
+			if !IsGiven(*bo.Get(ev.Pos)) {
+				t.Errorf("Bad candidates event: %v", ev)
+			}
+		case EventBranchFailed:
+			if !inFailed {
+				failedDepth = ev.Depth
+			}
+		case EventGivenForced:
+			if ev.Rect.Given != ev.Pos || (!inFailed && bo.Get(ev.Pos).Final != ev.Rect) {
+				t.Errorf("Given forced to the wrong Rect: %v", ev)
+			}
+		case EventBlankForced:
+			if IsGiven(*bo.Get(ev.Pos)) || (!inFailed && bo.Get(ev.Pos).Final != ev.Rect) {
+				t.Errorf("Blank forced to the wrong Rect: %v", ev)
+			}
+		case EventBranch:
+			if ev.Depth != 0 {
+				t.Errorf("Guessed at depth %d, expected 0: %v", ev.Depth, ev)
+			}
+			if ev.Depth == failedDepth {
+				failedDepth = -1
+			}
+		}
+	}
+
+	if t.Failed() {
+		for _, ev := range events {
+			t.Log(ev)
+		}
+	}
+}
+
+func TestObserverOverlap(t *testing.T) {
+	bo, _ := NewBoardFromString(testOverlapBoard)
+
+	overlaps := []Event{}
+	stats, err := bo.SolveContext(context.Background(), SolveOptions{
+		Observer: ObserverFunc(func(ev Event) {
+			if ev.Kind == EventOverlap {
+				overlaps = append(overlaps, ev)
+			}
+		}),
+	})
+	if err != nil {
+		t.Fatal("Couldn't find solution to solvable puzzle:", err)
+	}
+	if stats.Guessed != 0 {
+		t.Errorf("Guessed %d times, expected none", stats.Guessed)
+	}
+
+	if len(overlaps) == 0 {
+		t.Fatal("No Overlap events")
+	}
+	for _, ev := range overlaps {
+		if ev.Rect.Given != ev.Pos || !IsGiven(*bo.Get(ev.Pos)) {
+			t.Errorf("Bad overlap event: %v", ev)
+		}
+		bo.IterIn(ev.Rect.A, ev.Rect.B, func(pos Vec2, sq *Square) bool {
+			if sq.Final.Given != ev.Pos {
+				t.Errorf("%v: %v is covered by %v", ev, pos, sq.Final.Given)
+			}
+			return true
+		})
+	}
+}
+
+func TestObserverProbe(t *testing.T) {
+	bo, _ := NewBoardFromString(testBranchBoard)
+
+	probes := []Event{}
+	stats, err := bo.SolveContext(context.Background(), SolveOptions{
+		Observer: ObserverFunc(func(ev Event) {
+			if ev.Kind == EventProbe {
+				probes = append(probes, ev)
+			}
+		}),
+	})
+	if err != nil {
+		t.Fatal("Couldn't find solution to solvable puzzle:", err)
+	}
+	if stats.Guessed != 0 {
+		t.Errorf("Guessed %d times, expected none", stats.Guessed)
+	}
+
+	if len(probes) == 0 {
+		t.Fatal("No Probe events")
+	} else if len(probes) != stats.Probed {
+		t.Errorf("%d Probe events, for %d Rects probed", len(probes), stats.Probed)
+	}
+	for _, ev := range probes {
+		if ev.Rect.Given != ev.Pos || bo.Get(ev.Pos).Final == ev.Rect {
+			t.Errorf("Bad probe event: %v", ev)
+		}
+	}
+}
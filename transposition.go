@@ -0,0 +1,69 @@
+package shikaku
+
+import "sync/atomic"
+
+// tableSize is the number of failed states the transposition table can
+// remember at once.
+const tableSize = 1 << 12
+
+// table is a transposition table, holding the hashes of states which have
+// been shown to have no solution. Different orders of guesses often lead to
+// the same Rects being placed, and there's no point proving again that those
+// don't work.
+//
+// Each hash can only go in one slot, so a newer hash replaces an older one
+// which needs the same slot. It's safe to use from many goroutines at once.
+type table []uint64
+
+// newTable creates an empty transposition table.
+func newTable() table {
+	return make(table, tableSize)
+}
+
+// has returns true if hash is in the table.
+func (t table) has(hash uint64) bool {
+	return atomic.LoadUint64(&t[hash%uint64(len(t))]) == hash
+}
+
+// add adds hash to the table.
+func (t table) add(hash uint64) {
+	atomic.StoreUint64(&t[hash%uint64(len(t))], hash)
+}
+
+// zobrist returns the random key for candidate id. The hash of a state is its
+// placed candidates' keys XORed together, so it can be updated as each is
+// placed.
+func zobrist(id int) uint64 {
+	// Mixed, so keys don't need to be stored.
+	return splitmix(uint64(id + 1))
+}
+
+// splitmix scrambles x, using the finalizer of the splitmix64 generator.
+func splitmix(x uint64) uint64 {
+	z := x * 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// failed returns true if the transposition table holds st, counting a hit or
+// a miss.
+func (s *solver) failed(st *state) bool {
+	if atomic.LoadInt32(&s.tableReady) != 0 && s.table.has(st.hash) {
+		atomic.AddInt64(&s.tableHits, 1)
+		return true
+	}
+	atomic.AddInt64(&s.tableMisses, 1)
+	return false
+}
+
+// remember adds a state's hash to the transposition table, once it's been
+// shown to have no solution. The table is only created then, since until
+// something has failed, there's nothing to look up.
+func (s *solver) remember(hash uint64) {
+	s.tableOnce.Do(func() {
+		s.table = newTable()
+		atomic.StoreInt32(&s.tableReady, 1)
+	})
+	s.table.add(hash)
+}
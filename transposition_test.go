@@ -0,0 +1,66 @@
+package shikaku
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	tab := newTable()
+	if tab.has(42) {
+		t.Error("Empty table has 42")
+	}
+
+	tab.add(42)
+	if !tab.has(42) || tab.has(43) {
+		t.Error("Table should only have 42")
+	}
+
+	// Only the newest of two hashes needing the same slot is kept.
+	tab.add(42 + tableSize)
+	if tab.has(42) || !tab.has(42+tableSize) {
+		t.Error("Newer hash didn't replace the older one")
+	}
+}
+
+func TestStateHash(t *testing.T) {
+	bo, _ := NewBoardFromString(testRegionsBoard)
+	st := newState(bo)
+
+	// The 1s don't collide with anything, so they can be placed in any
+	// order.
+	ids := []int{}
+	for g, pos := range st.givens {
+		if bo.Get(pos).Area == 1 {
+			ids = append(ids, st.first[g])
+		}
+	}
+
+	forward, backward := st.clone(), st.clone()
+	for i := range ids {
+		forward.place(ids[i])
+		backward.place(ids[len(ids)-1-i])
+	}
+	if forward.hash != backward.hash {
+		t.Errorf("Placing the same candidates in a different order gave hash %x, expected %x", backward.hash, forward.hash)
+	}
+	if forward.hash == st.hash {
+		t.Error("Placing candidates didn't change the hash")
+	}
+}
+
+func TestSolveTableStats(t *testing.T) {
+	boards := append([]string{testBranchBoard, testGuessBoard, testRegionsBoard}, testBoards...)
+	for _, boString := range boards {
+		bo, _ := NewBoardFromString(boString)
+		stats, err := bo.SolveContext(context.Background(), SolveOptions{NoProbing: true})
+		if err != nil {
+			t.Fatal("Couldn't find solution to solvable puzzle:", err)
+		}
+
+		// Every guess is looked up in the table first.
+		if stats.TableHits+stats.TableMisses != stats.Guessed {
+			t.Errorf("%d hits and %d misses for %d guesses", stats.TableHits, stats.TableMisses, stats.Guessed)
+		}
+	}
+}
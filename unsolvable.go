@@ -0,0 +1,143 @@
+package shikaku
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// UnsolvableError is returned when a board has no solution. It holds a small
+// set of givens and squares which can't be solved together: there's no way to
+// enclose all of the givens and cover all of the squares, without any Rects
+// overlapping, whatever the rest of the board does.
+type UnsolvableError struct {
+	// Givens and Squares are the positions in the conflict, in row-major
+	// order.
+	Givens  []Vec2
+	Squares []Vec2
+}
+
+// Error describes the conflict.
+func (e *UnsolvableError) Error() string {
+	givens := describePositions("given", e.Givens)
+	squares := describePositions("square", e.Squares)
+	all := ""
+	if len(e.Givens)+len(e.Squares) > 1 {
+		all = "all "
+	}
+
+	switch {
+	case len(e.Squares) == 0:
+		return fmt.Sprintf("Board can't be solved: %s can't %sbe enclosed", givens, all)
+	case len(e.Givens) == 0:
+		return fmt.Sprintf("Board can't be solved: %s can't %sbe covered", squares, all)
+	default:
+		return fmt.Sprintf("Board can't be solved: %s can't be enclosed while covering %s", givens, squares)
+	}
+}
+
+// Positions returns the givens and squares in the conflict together, in
+// row-major order.
+func (e *UnsolvableError) Positions() []Vec2 {
+	all := append(append([]Vec2{}, e.Givens...), e.Squares...)
+	sortPositions(all)
+	return all
+}
+
+// sortPositions sorts positions into row-major order.
+func sortPositions(positions []Vec2) {
+	sort.Slice(positions, func(i, j int) bool {
+		a, b := positions[i], positions[j]
+		return a[1] < b[1] || (a[1] == b[1] && a[0] < b[0])
+	})
+}
+
+// describePositions describes the things of a kind at positions, like "the
+// squares at [1,2] [3,4]".
+func describePositions(kind string, positions []Vec2) string {
+	strs := []string{}
+	for _, pos := range positions {
+		strs = append(strs, pos.String())
+	}
+	if len(positions) != 1 {
+		kind += "s"
+	}
+	return fmt.Sprintf("the %s at %s", kind, strings.Join(strs, " "))
+}
+
+// explainNodes is the most search nodes spent checking whether each subset of
+// a board's constraints can be satisfied. Subsets which take longer are
+// assumed to be satisfiable, so the explanation is bigger than it needs to be
+// rather than wrong.
+const explainNodes = 10000
+
+// explain finds out why a board which failed to solve with err is
+// unsolvable, returning an UnsolvableError, or err if it can't tell.
+//
+// Every given must be enclosed, and every square covered, so together they
+// can't be satisfied. It removes as many of these constraints as it can while
+// they still can't be, leaving the conflict.
+func explain(ctx context.Context, bo *Board, err error) error {
+	if isAbort(err) {
+		return err
+	}
+
+	d := newBoardDLX(bo)
+	cells := bo.Width() * bo.Height()
+
+	// The constraints, as columns of d. Givens come first, so the conflict
+	// is narrowed down to squares where it can be.
+	constraints := []int{}
+	for c := 1 + cells; c < len(d.size); c++ {
+		constraints = append(constraints, c)
+	}
+	bo.Iter(func(pos Vec2, sq *Square) bool {
+		if !IsGiven(*sq) {
+			constraints = append(constraints, 1+pos[1]*bo.Width()+pos[0])
+		}
+		return true
+	})
+
+	// satisfiable returns true if the columns in keep can be satisfied, or if
+	// it can't tell in time.
+	satisfiable := func(keep []int) bool {
+		d.setPrimary(keep)
+		d.chosen = d.chosen[:0]
+		searchErr := d.search(newSolver(ctx, SolveOptions{MaxNodes: explainNodes}))
+		return searchErr == nil || isAbort(searchErr)
+	}
+	if satisfiable(constraints) {
+		return err
+	}
+
+	// Try removing chunks of the constraints, halving the chunks each time
+	// until single constraints are tried.
+	for chunk := len(constraints) / 2; chunk >= 1; chunk /= 2 {
+		for i := 0; i < len(constraints); {
+			end := i + chunk
+			if end > len(constraints) {
+				end = len(constraints)
+			}
+
+			rest := append(append([]int{}, constraints[:i]...), constraints[end:]...)
+			if !satisfiable(rest) {
+				constraints = rest
+			} else {
+				i = end
+			}
+		}
+	}
+
+	unsolvable := &UnsolvableError{Givens: []Vec2{}, Squares: []Vec2{}}
+	for _, c := range constraints {
+		if c > cells {
+			unsolvable.Givens = append(unsolvable.Givens, d.pos[c])
+		} else {
+			unsolvable.Squares = append(unsolvable.Squares, d.pos[c])
+		}
+	}
+	sortPositions(unsolvable.Givens)
+	sortPositions(unsolvable.Squares)
+	return unsolvable
+}
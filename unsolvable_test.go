@@ -0,0 +1,77 @@
+package shikaku
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestUnsolvable(t *testing.T) {
+	type testCase struct {
+		Board   string
+		Givens  []Vec2
+		Squares []Vec2
+		Err     string
+	}
+
+	tests := []testCase{
+		{
+			`03 -- 03
+			 -- -- --`,
+			[]Vec2{},
+			[]Vec2{{2, 1}},
+			"Board can't be solved: the square at [2,1] can't be covered",
+		},
+		{
+			`03 -- -- 05
+			 -- -- -- --
+			 -- -- 04 --`,
+			[]Vec2{},
+			[]Vec2{{1, 1}, {3, 1}},
+			"Board can't be solved: the squares at [1,1] [3,1] can't all be covered",
+		},
+		{
+			`02 01 -- -- 06
+			 -- -- -- -- --
+			 -- 06 -- -- --`,
+			[]Vec2{{1, 2}},
+			[]Vec2{{2, 0}},
+			"Board can't be solved: the given at [1,2] can't be enclosed while covering the square at [2,0]",
+		},
+	}
+
+	for _, name := range Solvers() {
+		for _, test := range tests {
+			t.Run(name, func(t *testing.T) {
+				bo, _ := NewBoardFromString(test.Board)
+				_, err := bo.SolveContext(context.Background(), SolveOptions{Solver: name})
+
+				unsolvable, ok := err.(*UnsolvableError)
+				if !ok {
+					t.Fatalf("Expected an UnsolvableError, got %v", err)
+				}
+				if !reflect.DeepEqual(unsolvable.Givens, test.Givens) || !reflect.DeepEqual(unsolvable.Squares, test.Squares) {
+					t.Errorf("Expected givens %v and squares %v, got %v and %v",
+						test.Givens, test.Squares, unsolvable.Givens, unsolvable.Squares)
+				}
+				if err.Error() != test.Err {
+					t.Errorf("Expected error %q, got %q", test.Err, err.Error())
+				}
+			})
+		}
+	}
+}
+
+func TestUnsolvableCanceled(t *testing.T) {
+	bo, _ := NewBoardFromString(`
+		03 -- 03
+		-- -- --`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := bo.SolveContext(ctx, SolveOptions{})
+	if err != ErrCanceled {
+		t.Errorf("Expected ErrCanceled from a canceled context, got %v", err)
+	}
+}
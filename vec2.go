@@ -36,6 +36,26 @@ func (v Vec2) String() string {
 	return fmt.Sprintf("[%d,%d]", v[0], v[1])
 }
 
+// max2 returns the larger of each coordinate of a and b.
+func max2(a, b Vec2) Vec2 {
+	for i := range a {
+		if b[i] > a[i] {
+			a[i] = b[i]
+		}
+	}
+	return a
+}
+
+// min2 returns the smaller of each coordinate of a and b.
+func min2(a, b Vec2) Vec2 {
+	for i := range a {
+		if b[i] < a[i] {
+			a[i] = b[i]
+		}
+	}
+	return a
+}
+
 // Factor finds all the integer factor pairs of x, sorted by the smallest factor.
 func Factor(x int) []Vec2 {
 	factors := []Vec2{}
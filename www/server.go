@@ -16,14 +16,16 @@ import (
 )
 
 func LoadTemplateFuncs(name string, funcs template.FuncMap) *template.Template {
-	tmpl := LoadTemplate(name)
-	tmpl = tmpl.Funcs(funcs)
-	return tmpl
+	// The funcs have to be there before parsing, for the templates to use them.
+	tmpl := template.New("base.html").Funcs(funcs)
+	return parseTemplate(tmpl, name)
 }
 
 func LoadTemplate(name string) *template.Template {
-	tmpl := template.New("base.html")
+	return parseTemplate(template.New("base.html"), name)
+}
 
+func parseTemplate(tmpl *template.Template, name string) *template.Template {
 	tmpl, err := tmpl.ParseFiles("templates/base.html", filepath.Join("templates", name+".html"))
 	if err != nil {
 		log.Fatalf("Couldn't load template '%s': %v", name, err)
@@ -2,6 +2,8 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
@@ -12,6 +14,9 @@ import (
 	"github.com/wgoodall01/shikaku"
 )
 
+// solveTimeout is the longest a single board may be worked on.
+const solveTimeout = 20 * time.Second
+
 type solveHandler struct {
 	http.Handler
 
@@ -23,6 +28,9 @@ func Solve() http.Handler {
 		"add": func(a, b int) int {
 			return a + b
 		},
+		"ms": func(d time.Duration) float64 {
+			return d.Seconds() * 1000
+		},
 	})
 
 	return &solveHandler{
@@ -59,6 +67,15 @@ func (h *solveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	opts := shikaku.SolveOptions{}
+	if names := r.Form["solver"]; len(names) > 0 {
+		if _, err := shikaku.LookupSolver(names[0]); err != nil {
+			WriteError(w, 400, "Unknown solver", err)
+			return
+		}
+		opts.Solver = names[0]
+	}
+
 	// Allocate board
 	bo := &shikaku.Board{}
 	for r := 0; r < rows; r++ {
@@ -101,10 +118,23 @@ func (h *solveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Solve the puzzle
-	tStart := time.Now()
-	solveErr := bo.Solve()
-	duration := time.Since(tStart).Seconds() / 1000 // in ms
+	// Solve the puzzle, giving up if the client goes away or it takes too long.
+	ctx, cancel := context.WithTimeout(r.Context(), solveTimeout)
+	defer cancel()
+
+	stats, solveErr := bo.SolveContext(ctx, opts)
+	if solveErr == shikaku.ErrCanceled && ctx.Err() == context.DeadlineExceeded {
+		solveErr = fmt.Errorf("Gave up after %v without finding a solution", solveTimeout)
+	}
+
+	// Highlight where the board is broken, if the solver could tell.
+	conflict := map[shikaku.Vec2]bool{}
+	var unsolvable *shikaku.UnsolvableError
+	if errors.As(solveErr, &unsolvable) {
+		for _, pos := range unsolvable.Positions() {
+			conflict[pos] = true
+		}
+	}
 
 	// Build the table.
 	buf := bytes.Buffer{}
@@ -122,9 +152,17 @@ func (h *solveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprintf(&buf, `<td class="solve_label">%d</td>`, pos[1]+1)
 		for pos[0] = 0; pos[0] < bo.Width(); pos[0]++ {
 			sq := bo.Get(pos)
-			if shikaku.IsNotFinal(*sq) {
+			class := ""
+			if conflict[pos] {
+				class = " solve_conflict"
+			}
+
+			if shikaku.IsUnsolvedGiven(*sq) {
+				// Write given on its own
+				fmt.Fprintf(&buf, `<td class="solve_given%s">%d</td>`, class, sq.Area)
+			} else if shikaku.IsNotFinal(*sq) {
 				// Write empty square
-				fmt.Fprint(&buf, `<td class="solve_empty"></td>`)
+				fmt.Fprintf(&buf, `<td class="solve_empty%s"></td>`, class)
 			} else if sq.Final.A == pos {
 				// It's the top-left, write a cell w/ colspan and rowspan.
 				colspan := sq.Final.Width()
@@ -146,12 +184,14 @@ func (h *solveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		Err      error
 		Soln     template.HTML
 		Small    bool
-		Duration float64
+		Stats    shikaku.SolveStats
+		Conflict bool
 	}{
 		Err:      solveErr,
 		Soln:     template.HTML(buf.String()),
 		Small:    rows > 15 || cols > 15,
-		Duration: duration,
+		Stats:    stats,
+		Conflict: len(conflict) > 0,
 	}
 
 	if err := h.tmpl.Execute(w, viewState); err != nil {
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 )
 
@@ -26,5 +27,35 @@ func TestSolveBasic(t *testing.T) {
 		t.Errorf("response status code %d", rr.Code)
 	}
 
+	if !strings.Contains(rr.Body.String(), "Nodes explored") {
+		t.Error("response doesn't show the solve stats")
+	}
+
+	t.Log(rr.Body.String())
+}
+
+func TestSolveUnsolvable(t *testing.T) {
+	handler := Solve()
+
+	body, _ := url.ParseQuery("rows=2&cols=3&e=3&e=&e=3&e=&e=&e=")
+	req, err := http.NewRequest("POST", "/solve", nil)
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.PostForm = body
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != 200 {
+		t.Errorf("response status code %d", rr.Code)
+	}
+
+	if !strings.Contains(rr.Body.String(), `<td class="solve_empty solve_conflict"></td>`) {
+		t.Error("response doesn't highlight the square which can't be covered")
+	}
+
 	t.Log(rr.Body.String())
 }